@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"afdianapi/internal/auth"
+
+	"gorm.io/gorm"
+)
+
+const apiKeyUsage = `用法:
+  server apikey create -name <名称> -scopes <权限,...>
+  server apikey list
+  server apikey revoke -id <ID>
+
+可用权限: read:sponsors, read:orders, send:msg, admin`
+
+// runAPIKeyCommand 管理 API 密钥，用于在没有任何管理员密钥时创建第一个密钥
+func runAPIKeyCommand(database *gorm.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("缺少子命令\n%s", apiKeyUsage)
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+		name := fs.String("name", "", "密钥名称")
+		rawScopes := fs.String("scopes", "", "逗号分隔的权限列表")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *name == "" {
+			return fmt.Errorf("缺少 -name\n%s", apiKeyUsage)
+		}
+
+		scopes, err := auth.ParseScopes(*rawScopes)
+		if err != nil {
+			return err
+		}
+
+		plain, key, err := auth.CreateKey(ctx, database, *name, scopes)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已创建密钥 #%d（%s），请妥善保存，明文不会再次显示:\n%s\n", key.ID, key.Name, plain)
+		return nil
+
+	case "list":
+		keys, err := auth.ListKeys(ctx, database)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\t名称\t前缀\t权限\t创建时间\t状态")
+		for _, key := range keys {
+			status := "有效"
+			if key.RevokedAt != nil {
+				status = "已吊销"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+				key.ID, key.Name, key.Prefix, key.Scopes,
+				time.Unix(key.CreatedAt, 0).Format(time.DateTime), status)
+		}
+		return w.Flush()
+
+	case "revoke":
+		fs := flag.NewFlagSet("apikey revoke", flag.ContinueOnError)
+		id := fs.Uint("id", 0, "密钥 ID")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *id == 0 {
+			return fmt.Errorf("缺少 -id\n%s", apiKeyUsage)
+		}
+
+		if err := auth.RevokeKey(ctx, database, *id); err != nil {
+			return err
+		}
+		fmt.Printf("已吊销密钥 #%d\n", *id)
+		return nil
+	}
+
+	return fmt.Errorf("未知子命令: %s\n%s", args[0], apiKeyUsage)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -10,13 +11,16 @@ import (
 	"syscall"
 	"time"
 
+	"afdianapi/internal/cache"
 	"afdianapi/internal/config"
 	"afdianapi/internal/cron"
 	"afdianapi/internal/db"
+	"afdianapi/internal/events"
 	"afdianapi/internal/routes"
 	"afdianapi/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -25,25 +29,51 @@ func main() {
 		log.Fatalf("配置加载失败: %v", err)
 	}
 
+	// 子命令只建立连接，不要求迁移已执行，migrate 本身即在此路径运行
+	if len(os.Args) > 1 {
+		database, err := db.Connect(cfg)
+		if err != nil {
+			log.Fatalf("数据库连接失败: %v", err)
+		}
+		if err := runCommand(database, os.Args[1:]); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+
 	database, err := db.Init(cfg)
 	if err != nil {
 		log.Fatalf("数据库初始化失败: %v", err)
 	}
 
+	afdianClient, err := services.NewAfdianClient(cfg)
+	if err != nil {
+		log.Fatalf("爱发电客户端初始化失败: %v", err)
+	}
+
+	responseCache, err := cache.New(cfg.Cache)
+	if err != nil {
+		log.Fatalf("缓存初始化失败: %v", err)
+	}
+
+	scheduler := cron.NewScheduler(cfg, database, afdianClient, responseCache)
+	eventHub := events.NewHub(cfg.Events, database)
+
 	router := gin.New()
 	router.Use(gin.Logger(), gin.Recovery())
-	routes.Register(router, database)
+	routes.Register(router, cfg, database, afdianClient, scheduler, responseCache, eventHub)
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
 		Handler: router,
 	}
 
-	afdianClient := services.NewAfdianClient(cfg)
-	scheduler := cron.NewScheduler(cfg, database, afdianClient)
 	if err := scheduler.Start(); err != nil {
 		log.Fatalf("定时任务启动失败: %v", err)
 	}
+	if err := eventHub.Start(); err != nil {
+		log.Fatalf("事件推送启动失败: %v", err)
+	}
 
 	go func() {
 		log.Printf("服务器已启动: http://%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -62,12 +92,31 @@ func main() {
 	defer cancel()
 
 	scheduler.Stop()
+	// 先关闭事件订阅，使长连接的 /events 请求返回，否则 Shutdown 会一直等待到超时
+	eventHub.Stop()
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Printf("HTTP 服务关闭失败: %v", err)
 	}
+	if closer, ok := responseCache.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("缓存关闭失败: %v", err)
+		}
+	}
 	if err := db.Close(); err != nil {
 		log.Printf("数据库关闭失败: %v", err)
 	}
 
 	log.Println("服务已关闭")
 }
+
+func runCommand(database *gorm.DB, args []string) error {
+	defer db.Close()
+
+	switch args[0] {
+	case "apikey":
+		return runAPIKeyCommand(database, args[1:])
+	case "migrate":
+		return runMigrateCommand(database, args[1:])
+	}
+	return fmt.Errorf("未知命令: %s", args[0])
+}
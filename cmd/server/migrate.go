@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"afdianapi/internal/db"
+
+	"gorm.io/gorm"
+)
+
+const migrateUsage = `用法:
+  server migrate up [-to <版本>] [-dry-run]
+  server migrate down [-steps <数量>] [-dry-run]
+  server migrate status`
+
+// runMigrateCommand 执行、回滚或查看版本化数据库迁移
+func runMigrateCommand(database *gorm.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("缺少子命令\n%s", migrateUsage)
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		fs := flag.NewFlagSet("migrate up", flag.ContinueOnError)
+		target := fs.Int("to", 0, "执行到指定版本（含），默认执行全部")
+		dryRun := fs.Bool("dry-run", false, "只输出将要执行的迁移及其 SQL，不修改数据库")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		applied, err := db.MigrateUp(ctx, database, *target, *dryRun)
+		if err == nil && *dryRun {
+			return printMigrationSQL(applied, "执行", func(migration db.Migration) ([]string, error) {
+				return db.PreviewUp(ctx, database, migration)
+			})
+		}
+		printMigrations(applied, *dryRun, "执行")
+		return err
+
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "回滚的迁移数量")
+		dryRun := fs.Bool("dry-run", false, "只输出将要回滚的迁移及其 SQL，不修改数据库")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *steps < 1 {
+			return fmt.Errorf("-steps 必须大于 0\n%s", migrateUsage)
+		}
+
+		reverted, err := db.MigrateDown(ctx, database, *steps, *dryRun)
+		if err == nil && *dryRun {
+			return printMigrationSQL(reverted, "回滚", func(migration db.Migration) ([]string, error) {
+				return db.PreviewDown(ctx, database, migration)
+			})
+		}
+		printMigrations(reverted, *dryRun, "回滚")
+		return err
+
+	case "status":
+		statuses, err := db.MigrationStatuses(ctx, database)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "版本\t名称\t状态\t执行时间")
+		for _, status := range statuses {
+			state, appliedAt := "未执行", "-"
+			if status.Applied {
+				state = "已执行"
+				appliedAt = time.Unix(*status.AppliedAt, 0).Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		return w.Flush()
+	}
+
+	return fmt.Errorf("未知子命令: %s\n%s", args[0], migrateUsage)
+}
+
+func printMigrations(list []db.Migration, dryRun bool, action string) {
+	if len(list) == 0 {
+		fmt.Printf("没有需要%s的迁移\n", action)
+		return
+	}
+
+	prefix := "已" + action
+	if dryRun {
+		prefix = "将" + action
+	}
+	for _, migration := range list {
+		fmt.Printf("%s %d_%s\n", prefix, migration.Version, migration.Name)
+	}
+}
+
+// printMigrationSQL 输出 -dry-run 时每个迁移将要执行的 SQL。多个迁移的语句均基于当前库结构生成
+func printMigrationSQL(list []db.Migration, action string, preview func(migration db.Migration) ([]string, error)) error {
+	if len(list) == 0 {
+		fmt.Printf("没有需要%s的迁移\n", action)
+		return nil
+	}
+
+	for _, migration := range list {
+		fmt.Printf("-- 将%s %d_%s\n", action, migration.Version, migration.Name)
+		statements, err := preview(migration)
+		if err != nil {
+			return fmt.Errorf("生成迁移 %d_%s 的 SQL 失败: %w", migration.Version, migration.Name, err)
+		}
+		if len(statements) == 0 {
+			fmt.Println("-- 无需变更")
+		}
+		for _, statement := range statements {
+			fmt.Printf("%s;\n", statement)
+		}
+		fmt.Println()
+	}
+	return nil
+}
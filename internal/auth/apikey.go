@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"afdianapi/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ScopeReadSponsors = "read:sponsors"
+	ScopeReadOrders   = "read:orders"
+	ScopeSendMsg      = "send:msg"
+	// ScopeAdmin 包含全部权限
+	ScopeAdmin = "admin"
+)
+
+// AllScopes 全部可分配的权限
+var AllScopes = []string{ScopeReadSponsors, ScopeReadOrders, ScopeSendMsg, ScopeAdmin}
+
+// keyPrefix 明文密钥的固定前缀，便于在日志与代码扫描中识别
+const keyPrefix = "afd_"
+
+// lastUsedInterval 最近使用时间的最小更新间隔，避免每个请求都写库
+const lastUsedInterval = time.Minute
+
+var (
+	ErrInvalidKey   = errors.New("密钥无效")
+	ErrKeyNotFound  = errors.New("密钥不存在")
+	ErrInvalidScope = errors.New("权限无效")
+)
+
+// HashKey 计算明文密钥的摘要。密钥为 256 位随机数，直接使用 SHA-256 即可抵御暴力破解
+func HashKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseScopes 解析逗号分隔的权限列表，去重并校验
+func ParseScopes(raw string) ([]string, error) {
+	scopes := make([]string, 0)
+	for _, scope := range strings.Split(raw, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || slices.Contains(scopes, scope) {
+			continue
+		}
+		if !slices.Contains(AllScopes, scope) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: 至少需要一个权限", ErrInvalidScope)
+	}
+	return scopes, nil
+}
+
+// HasScope 判断密钥是否拥有指定权限，admin 拥有全部权限
+func HasScope(key *models.APIKey, scope string) bool {
+	scopes := strings.Split(key.Scopes, ",")
+	return slices.Contains(scopes, ScopeAdmin) || slices.Contains(scopes, scope)
+}
+
+// CreateKey 生成并保存新密钥，明文仅在此处返回一次
+func CreateKey(ctx context.Context, db *gorm.DB, name string, scopes []string) (string, *models.APIKey, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("生成密钥失败: %w", err)
+	}
+	plain := keyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	key := &models.APIKey{
+		Name:      name,
+		Prefix:    plain[:len(keyPrefix)+8],
+		KeyHash:   HashKey(plain),
+		Scopes:    strings.Join(scopes, ","),
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := db.WithContext(ctx).Create(key).Error; err != nil {
+		return "", nil, fmt.Errorf("保存密钥失败: %w", err)
+	}
+	return plain, key, nil
+}
+
+// RevokeKey 吊销密钥，已吊销的密钥重复吊销不报错
+func RevokeKey(ctx context.Context, db *gorm.DB, id uint) error {
+	now := time.Now().Unix()
+	result := db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ?", id).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var count int64
+	if err := db.WithContext(ctx).Model(&models.APIKey{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+// ListKeys 按创建时间倒序返回全部密钥
+func ListKeys(ctx context.Context, db *gorm.DB) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := db.WithContext(ctx).Order("id desc").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Authenticate 校验明文密钥并返回对应记录，已吊销或不存在时返回 ErrInvalidKey
+func Authenticate(ctx context.Context, db *gorm.DB, plain string) (*models.APIKey, error) {
+	if !strings.HasPrefix(plain, keyPrefix) {
+		return nil, ErrInvalidKey
+	}
+
+	var keys []models.APIKey
+	if err := db.WithContext(ctx).
+		Where("key_hash = ?", HashKey(plain)).
+		Where("revoked_at IS NULL").
+		Limit(1).
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, ErrInvalidKey
+	}
+	key := &keys[0]
+
+	now := time.Now().Unix()
+	if key.LastUsedAt == nil || now-*key.LastUsedAt >= int64(lastUsedInterval/time.Second) {
+		if err := db.WithContext(ctx).Model(&models.APIKey{}).
+			Where("id = ?", key.ID).
+			Update("last_used_at", now).Error; err == nil {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
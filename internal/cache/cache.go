@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"afdianapi/internal/config"
+)
+
+// Cache 接口响应缓存，值为序列化后的响应体。实现需并发安全；
+// 读写失败时调用方应回退到数据库查询，而不是返回错误
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Generation 返回当前缓存代数，每次 Invalidate 后递增。
+	// 调用方应在读取数据库之前获取代数，并在写入时传给 Set
+	Generation(ctx context.Context) (uint64, error)
+	// Set 以 generation 代写入缓存；期间发生过 Invalidate 时写入被丢弃，
+	// 避免失效前读到的旧数据在失效后重新进入缓存
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, generation uint64) error
+	// Invalidate 使当前全部缓存失效，多副本部署时对所有副本生效（取决于实现）
+	Invalidate(ctx context.Context) error
+}
+
+// New 按 CACHE_DRIVER 创建缓存后端
+func New(cfg config.CacheConfig) (Cache, error) {
+	switch cfg.Driver {
+	case config.CacheDriverMemory:
+		return NewMemory(cfg.MaxEntries), nil
+	case config.CacheDriverRedis:
+		return NewRedis(cfg)
+	}
+	return nil, fmt.Errorf("不支持的缓存驱动: %s", cfg.Driver)
+}
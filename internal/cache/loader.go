@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// LoadFunc 缓存未命中时生成缓存值
+type LoadFunc func(ctx context.Context) ([]byte, error)
+
+// Loader 在 Cache 之上合并并发的未命中请求：同一个键同时只有一个协程执行 LoadFunc，
+// 其余请求等待并共享结果。staleTTL 大于 0 时，条目过期后的 staleTTL 内仍直接返回旧值，
+// 同时在后台刷新（stale-while-revalidate）
+type Loader struct {
+	cache    Cache
+	ttl      time.Duration
+	staleTTL time.Duration
+	group    singleflight.Group
+}
+
+// NewLoader ttl 不大于 0 时不写入缓存，只合并并发请求
+func NewLoader(cache Cache, ttl time.Duration, staleTTL time.Duration) *Loader {
+	if staleTTL < 0 {
+		staleTTL = 0
+	}
+	return &Loader{
+		cache:    cache,
+		ttl:      ttl,
+		staleTTL: staleTTL,
+	}
+}
+
+// Get 返回 key 对应的值，未命中时调用 load 生成并写入缓存
+func (l *Loader) Get(ctx context.Context, key string, load LoadFunc) ([]byte, error) {
+	if l.ttl > 0 {
+		raw, ok, err := l.cache.Get(ctx, key)
+		if err != nil {
+			log.Printf("[缓存] 读取 %s 失败: %v", key, err)
+		}
+		if ok {
+			if freshUntil, value, valid := decodeEntry(raw); valid {
+				if time.Now().Before(freshUntil) {
+					return value, nil
+				}
+				// 已过期但仍在容忍窗口内：立即返回旧值，由后台刷新
+				l.group.DoChan(key, l.loadFunc(context.WithoutCancel(ctx), key, load))
+				return value, nil
+			}
+		}
+	}
+
+	// 加载不随单个请求取消，避免一个客户端断开导致共享同一次加载的其他请求一起失败
+	value, err, _ := l.group.Do(key, l.loadFunc(context.WithoutCancel(ctx), key, load))
+	if err != nil {
+		return nil, err
+	}
+	return value.([]byte), nil
+}
+
+func (l *Loader) loadFunc(ctx context.Context, key string, load LoadFunc) func() (any, error) {
+	return func() (any, error) {
+		// 在查询数据库之前取得代数，加载期间发生的失效会使本次写入被丢弃
+		var generation uint64
+		cacheable := l.ttl > 0
+		if cacheable {
+			var err error
+			if generation, err = l.cache.Generation(ctx); err != nil {
+				log.Printf("[缓存] 读取缓存代数失败，本次结果不写入缓存: %v", err)
+				cacheable = false
+			}
+		}
+
+		value, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if cacheable {
+			entry := encodeEntry(time.Now().Add(l.ttl), value)
+			if err := l.cache.Set(ctx, key, entry, l.ttl+l.staleTTL, generation); err != nil {
+				log.Printf("[缓存] 写入 %s 失败: %v", key, err)
+			}
+		}
+		return value, nil
+	}
+}
+
+// encodeEntry 在值前附加 8 字节的新鲜截止时间（Unix 毫秒，大端序）
+func encodeEntry(freshUntil time.Time, value []byte) []byte {
+	entry := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint64(entry, uint64(freshUntil.UnixMilli()))
+	copy(entry[8:], value)
+	return entry
+}
+
+func decodeEntry(entry []byte) (time.Time, []byte, bool) {
+	if len(entry) < 8 {
+		return time.Time{}, nil, false
+	}
+	freshUntil := time.UnixMilli(int64(binary.BigEndian.Uint64(entry)))
+	return freshUntil, entry[8:], true
+}
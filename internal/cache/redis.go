@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"afdianapi/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 多副本共享的缓存。失效通过递增“代数”实现：缓存键中包含当前代数，
+// Invalidate 只需 INCR 代数键，旧代数的条目不再被读取并随 TTL 自然过期，无需扫描删除。
+// Set 写入调用方传入的代数，失效前开始的加载只会写到旧代数下，不会被读到
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedis(cfg config.CacheConfig) (*Redis, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("连接 Redis %s 失败: %w", cfg.RedisAddr, err)
+	}
+
+	return &Redis{
+		client: client,
+		prefix: cfg.RedisKeyPrefix,
+	}, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	generation, err := r.Generation(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, err := r.client.Get(ctx, r.key(generation, key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Generation 代数键不存在时视为 0
+func (r *Redis) Generation(ctx context.Context) (uint64, error) {
+	generation, err := r.client.Get(ctx, r.generationKey()).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return generation, err
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration, generation uint64) error {
+	return r.client.Set(ctx, r.key(generation, key), value, ttl).Err()
+}
+
+func (r *Redis) Invalidate(ctx context.Context) error {
+	return r.client.Incr(ctx, r.generationKey()).Err()
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+// key 返回包含代数的完整缓存键
+func (r *Redis) key(generation uint64, key string) string {
+	return r.prefix + "cache:" + strconv.FormatUint(generation, 10) + ":" + key
+}
+
+func (r *Redis) generationKey() string {
+	return r.prefix + "cache:generation"
+}
@@ -5,14 +5,24 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type AfdianConfig struct {
-	UserID   string
-	APIToken string
-	BaseURL  string
+	UserID              string
+	APIToken            string
+	BaseURL             string
+	WebhookPublicKey    string
+	RetryMaxAttempts    int
+	RetryBaseDelayMs    int
+	RetryMaxDelayMs     int
+	RetryableEc         []int
+	RetryableHTTPStatus []int
+	RateLimit           float64
+	RateBurst           int
 }
 
 type ServerConfig struct {
@@ -20,7 +30,26 @@ type ServerConfig struct {
 	Port int
 }
 
+// 数据库 TLS 模式
+const (
+	DBSSLModeDisable    = "disable"
+	DBSSLModeSkipVerify = "skip-verify"
+	// DBSSLModeVerifyCA 校验证书链，不校验主机名
+	DBSSLModeVerifyCA = "verify-ca"
+	// DBSSLModeVerifyFull 校验证书链与主机名
+	DBSSLModeVerifyFull = "verify-full"
+)
+
+const (
+	DBDriverMySQL    = "mysql"
+	DBDriverSQLite   = "sqlite"
+	DBDriverPostgres = "postgres"
+)
+
 type DatabaseConfig struct {
+	Driver string
+	// Path SQLite 数据库文件路径，":memory:" 表示内存数据库
+	Path            string
 	Host            string
 	Port            int
 	User            string
@@ -28,11 +57,48 @@ type DatabaseConfig struct {
 	Name            string
 	ConnectionLimit int
 	ConnectTimeout  int
-	SSL             bool
+	SSLMode         string
+	SSLCA           string
+	SSLCert         string
+	SSLKey          string
+	SSLServerName   string
+	// AutoMigrate 开发模式：启动时直接按模型 AutoMigrate，不检查版本化迁移
+	AutoMigrate bool
 }
 
 type CronConfig struct {
-	SyncCron string
+	SyncCron             string
+	FullSyncCron         string
+	OrderSyncCron        string
+	IncrementalStopAfter int
+	PruneEnabled         bool
+	PruneMaxRatio        float64
+}
+
+const (
+	CacheDriverMemory = "memory"
+	CacheDriverRedis  = "redis"
+)
+
+type CacheConfig struct {
+	Driver string
+	TTL    time.Duration
+	// StaleTTL 条目过期后仍可返回旧值并在后台刷新的时长，0 表示关闭
+	StaleTTL       time.Duration
+	MaxEntries     int
+	RedisAddr      string
+	RedisPassword  string
+	RedisDB        int
+	RedisKeyPrefix string
+}
+
+type EventsConfig struct {
+	// PollInterval 轮询事件日志的间隔，多副本部署时各副本都能推送其他副本写入的事件
+	PollInterval time.Duration
+	// Retention 事件日志保留时长，超过的事件被清理，无法再续传
+	Retention time.Duration
+	// ReplayLimit 断线续传时最多补发的事件数
+	ReplayLimit int
 }
 
 type Config struct {
@@ -40,6 +106,8 @@ type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	Cron     CronConfig
+	Cache    CacheConfig
+	Events   EventsConfig
 }
 
 func Load() (*Config, error) {
@@ -51,32 +119,102 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("缺少必需的环境变量: AFDIAN_USER_ID/AFDIAN_API_TOKEN")
 	}
 
-	if os.Getenv("NODE_ENV") == "production" && os.Getenv("DB_PASSWORD") == "" {
+	dbDriver := strings.ToLower(getEnvString("DB_DRIVER", DBDriverMySQL))
+	defaultDBPort := 3306
+	switch dbDriver {
+	case DBDriverMySQL, DBDriverSQLite:
+	case DBDriverPostgres:
+		defaultDBPort = 5432
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动 DB_DRIVER=%s，可选 mysql、sqlite、postgres", dbDriver)
+	}
+
+	// 兼容旧配置：仅设置 DB_SSL=true 时按最严格的 verify-full 处理
+	sslMode := strings.ToLower(getEnvString("DB_SSL_MODE", ""))
+	if sslMode == "" {
+		sslMode = DBSSLModeDisable
+		if getEnvBool("DB_SSL", false) {
+			sslMode = DBSSLModeVerifyFull
+		}
+	}
+	switch sslMode {
+	case DBSSLModeDisable, DBSSLModeSkipVerify, DBSSLModeVerifyCA, DBSSLModeVerifyFull:
+	default:
+		return nil, fmt.Errorf("不支持的 DB_SSL_MODE=%s，可选 disable、skip-verify、verify-ca、verify-full", sslMode)
+	}
+
+	sslCert := getEnvString("DB_SSL_CERT", "")
+	sslKey := getEnvString("DB_SSL_KEY", "")
+	if (sslCert == "") != (sslKey == "") {
+		return nil, fmt.Errorf("DB_SSL_CERT 与 DB_SSL_KEY 需要同时设置")
+	}
+
+	cacheDriver := strings.ToLower(getEnvString("CACHE_DRIVER", CacheDriverMemory))
+	if cacheDriver != CacheDriverMemory && cacheDriver != CacheDriverRedis {
+		return nil, fmt.Errorf("不支持的缓存驱动 CACHE_DRIVER=%s，可选 memory、redis", cacheDriver)
+	}
+
+	if os.Getenv("NODE_ENV") == "production" && dbDriver != DBDriverSQLite && os.Getenv("DB_PASSWORD") == "" {
 		log.Println("警告: 生产环境未设置数据库密码，存在安全风险")
 	}
 
 	return &Config{
 		Afdian: AfdianConfig{
-			UserID:   userID,
-			APIToken: apiToken,
-			BaseURL:  getEnvString("AFDIAN_API_BASE_URL", "https://afdian.com/api/open"),
+			UserID:              userID,
+			APIToken:            apiToken,
+			BaseURL:             getEnvString("AFDIAN_API_BASE_URL", "https://afdian.com/api/open"),
+			WebhookPublicKey:    getEnvString("AFDIAN_WEBHOOK_PUBLIC_KEY", ""),
+			RetryMaxAttempts:    getEnvInt("AFDIAN_RETRY_MAX_ATTEMPTS", 3),
+			RetryBaseDelayMs:    getEnvInt("AFDIAN_RETRY_BASE_DELAY_MS", 500),
+			RetryMaxDelayMs:     getEnvInt("AFDIAN_RETRY_MAX_DELAY_MS", 10000),
+			RetryableEc:         getEnvIntList("AFDIAN_RETRY_EC", []int{400002}),
+			RetryableHTTPStatus: getEnvIntList("AFDIAN_RETRY_HTTP_STATUS", []int{429, 500, 502, 503, 504}),
+			RateLimit:           getEnvFloat("AFDIAN_RATE_LIMIT", 2),
+			RateBurst:           getEnvInt("AFDIAN_RATE_BURST", 2),
 		},
 		Server: ServerConfig{
 			Host: getEnvString("HOST", "0.0.0.0"),
 			Port: getEnvInt("PORT", 3000),
 		},
 		Database: DatabaseConfig{
+			Driver:          dbDriver,
+			Path:            getEnvString("DB_PATH", "afdian.db"),
 			Host:            getEnvString("DB_HOST", "localhost"),
-			Port:            getEnvInt("DB_PORT", 3306),
+			Port:            getEnvInt("DB_PORT", defaultDBPort),
 			User:            getEnvString("DB_USER", "root"),
 			Password:        getEnvString("DB_PASSWORD", ""),
 			Name:            getEnvString("DB_NAME", "afdian"),
 			ConnectionLimit: getEnvInt("DB_CONNECTION_LIMIT", 10),
 			ConnectTimeout:  getEnvInt("DB_CONNECT_TIMEOUT", 10),
-			SSL:             getEnvBool("DB_SSL", false),
+			SSLMode:         sslMode,
+			SSLCA:           getEnvString("DB_SSL_CA", ""),
+			SSLCert:         sslCert,
+			SSLKey:          sslKey,
+			SSLServerName:   getEnvString("DB_SSL_SERVER_NAME", ""),
+			AutoMigrate:     getEnvBool("DB_AUTO_MIGRATE", false),
 		},
 		Cron: CronConfig{
-			SyncCron: getEnvString("SYNC_CRON", "*/5 * * * *"),
+			SyncCron:             getEnvString("SYNC_CRON", "*/5 * * * *"),
+			FullSyncCron:         getEnvString("FULL_SYNC_CRON", "0 4 * * *"),
+			OrderSyncCron:        getEnvString("ORDER_SYNC_CRON", "*/10 * * * *"),
+			IncrementalStopAfter: getEnvInt("SYNC_INCREMENTAL_STOP_AFTER", 20),
+			PruneEnabled:         getEnvBool("SYNC_PRUNE_ENABLED", true),
+			PruneMaxRatio:        getEnvFloat("SYNC_PRUNE_MAX_RATIO", 0.1),
+		},
+		Cache: CacheConfig{
+			Driver:         cacheDriver,
+			TTL:            time.Duration(getEnvInt("CACHE_TTL", 5)) * time.Second,
+			StaleTTL:       time.Duration(getEnvInt("CACHE_STALE_TTL", 0)) * time.Second,
+			MaxEntries:     getEnvInt("CACHE_MAX_ENTRIES", 1000),
+			RedisAddr:      getEnvString("REDIS_ADDR", "localhost:6379"),
+			RedisPassword:  getEnvString("REDIS_PASSWORD", ""),
+			RedisDB:        getEnvInt("REDIS_DB", 0),
+			RedisKeyPrefix: getEnvString("REDIS_KEY_PREFIX", "afdianapi:"),
+		},
+		Events: EventsConfig{
+			PollInterval: time.Duration(getEnvInt("EVENTS_POLL_INTERVAL_MS", 1000)) * time.Millisecond,
+			Retention:    time.Duration(getEnvInt("EVENTS_RETENTION_HOURS", 24)) * time.Hour,
+			ReplayLimit:  getEnvInt("EVENTS_REPLAY_LIMIT", 500),
 		},
 	}, nil
 }
@@ -97,6 +235,37 @@ func getEnvInt(key string, fallback int) int {
 	return fallback
 }
 
+func getEnvFloat(key string, fallback float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func getEnvIntList(key string, fallback []int) []int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parts := strings.Split(value, ",")
+	parsed := make([]int, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return fallback
+		}
+		parsed = append(parsed, number)
+	}
+	return parsed
+}
+
 func getEnvBool(key string, fallback bool) bool {
 	if value := os.Getenv(key); value != "" {
 		parsed, err := strconv.ParseBool(value)
@@ -0,0 +1,90 @@
+package cron
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"afdianapi/internal/models"
+)
+
+// maxRunErrors 单次运行最多记录的错误条数
+const maxRunErrors = 20
+
+// runTracker 累计一次同步的统计数据与错误，结束时写入 sync_runs
+type runTracker struct {
+	run    models.SyncRun
+	errors []string
+}
+
+func (t *runTracker) fail(err error) {
+	if len(t.errors) < maxRunErrors {
+		t.errors = append(t.errors, err.Error())
+	}
+}
+
+// beginRun 写入一条 running 状态的运行记录，写入失败不影响同步本身
+func (s *SyncService) beginRun(ctx context.Context, job string, trigger string, mode string) *runTracker {
+	tracker := &runTracker{
+		run: models.SyncRun{
+			Job:       job,
+			Trigger:   trigger,
+			Mode:      mode,
+			Status:    models.SyncRunStatusRunning,
+			StartedAt: time.Now().Unix(),
+		},
+	}
+	if err := s.db.WithContext(ctx).Create(&tracker.run).Error; err != nil {
+		log.Printf("[定时任务] 写入同步记录失败: %v", err)
+	}
+	s.reportProgress(tracker)
+	return tracker
+}
+
+// reportProgress 发布当前运行的统计快照，供状态查询读取
+func (s *SyncService) reportProgress(tracker *runTracker) {
+	s.mu.Lock()
+	s.progress[tracker.run.Job] = tracker.run
+	s.mu.Unlock()
+}
+
+// Progress 返回指定任务正在进行的运行快照，没有进行中的运行时返回 false
+func (s *SyncService) Progress(job string) (models.SyncRun, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run, ok := s.progress[job]
+	return run, ok
+}
+
+// finishRun 根据取消状态与错误情况确定最终状态并保存运行记录。
+// 使用脱离取消的 context，确保关闭过程中被取消的运行也能落库。
+func (s *SyncService) finishRun(ctx context.Context, tracker *runTracker) {
+	run := &tracker.run
+	switch {
+	case ctx.Err() != nil:
+		run.Status = models.SyncRunStatusCancelled
+	case len(tracker.errors) > 0 && run.PagesFetched == 0:
+		run.Status = models.SyncRunStatusFailed
+	case len(tracker.errors) > 0:
+		run.Status = models.SyncRunStatusPartial
+	default:
+		run.Status = models.SyncRunStatusSuccess
+	}
+
+	finishedAt := time.Now().Unix()
+	run.FinishedAt = &finishedAt
+	if len(tracker.errors) > 0 {
+		message := strings.Join(tracker.errors, "\n")
+		run.Error = &message
+	}
+
+	if err := s.db.WithContext(context.WithoutCancel(ctx)).Save(run).Error; err != nil {
+		log.Printf("[定时任务] 保存同步记录失败: %v", err)
+	}
+
+	s.mu.Lock()
+	delete(s.progress, run.Job)
+	s.mu.Unlock()
+}
@@ -1,12 +1,18 @@
 package cron
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"strconv"
 	"sync"
 	"time"
 
+	"afdianapi/internal/cache"
 	"afdianapi/internal/config"
+	"afdianapi/internal/db"
+	"afdianapi/internal/events"
 	"afdianapi/internal/models"
 	"afdianapi/internal/services"
 
@@ -15,137 +21,292 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+// ErrSyncInProgress 同一任务已有同步在进行中
+var ErrSyncInProgress = errors.New("同步正在进行中")
+
+// ErrSchedulerStopped 调度器已停止，不再接受新的同步任务
+var ErrSchedulerStopped = errors.New("定时任务已停止")
+
 type SyncService struct {
-	db        *gorm.DB
-	client    *services.AfdianClient
-	mu        sync.Mutex
-	isSyncing bool
+	db                   *gorm.DB
+	client               *services.AfdianClient
+	cache                cache.Cache
+	incrementalStopAfter int
+	pruneEnabled         bool
+	pruneMaxRatio        float64
+	mu                   sync.Mutex
+	isSyncing            bool
+	isSyncingOrders      bool
+	fullSyncPending      bool
+	pendingTrigger       string
+	progress             map[string]models.SyncRun
 }
 
-func NewSyncService(db *gorm.DB, client *services.AfdianClient) *SyncService {
+// SyncMode 赞助者同步模式
+type SyncMode string
+
+const (
+	// SyncModeFull 遍历全部分页
+	SyncModeFull SyncMode = "full"
+	// SyncModeIncremental 连续遇到若干条未变化的记录后停止翻页
+	SyncModeIncremental SyncMode = "incremental"
+)
+
+func NewSyncService(cfg *config.Config, db *gorm.DB, client *services.AfdianClient, responseCache cache.Cache) *SyncService {
+	stopAfter := cfg.Cron.IncrementalStopAfter
+	if stopAfter < 1 {
+		stopAfter = 1
+	}
+
 	return &SyncService{
-		db:     db,
-		client: client,
+		db:                   db,
+		client:               client,
+		cache:                responseCache,
+		incrementalStopAfter: stopAfter,
+		pruneEnabled:         cfg.Cron.PruneEnabled,
+		pruneMaxRatio:        cfg.Cron.PruneMaxRatio,
+		progress:             make(map[string]models.SyncRun),
 	}
 }
 
-func (s *SyncService) SyncSponsors() {
-	s.mu.Lock()
-	if s.isSyncing {
+// SyncSponsors 同步赞助者数据。全量同步遇到正在进行的同步时不会被丢弃，
+// 而是在当前同步结束后立即执行。
+func (s *SyncService) SyncSponsors(ctx context.Context, mode SyncMode, trigger string) {
+	if !s.tryBegin(&s.isSyncing) {
+		if mode == SyncModeFull {
+			s.mu.Lock()
+			s.fullSyncPending = true
+			s.pendingTrigger = trigger
+			s.mu.Unlock()
+			log.Println("[定时任务] 上一次同步仍在进行中，全量同步将在其结束后执行")
+			return
+		}
 		log.Println("[定时任务] 上一次同步仍在进行中，跳过本次执行")
-		s.mu.Unlock()
 		return
 	}
-	s.isSyncing = true
-	s.mu.Unlock()
+	s.runSponsors(ctx, mode, trigger)
+}
+
+// StartSponsors 在后台发起一次赞助者同步，已有同步在进行时返回 ErrSyncInProgress。
+// spawn 负责启动后台任务，由调用方管理其生命周期；spawn 返回错误时任务不会执行。
+func (s *SyncService) StartSponsors(mode SyncMode, trigger string, spawn func(job func(ctx context.Context)) error) error {
+	if !s.tryBegin(&s.isSyncing) {
+		return ErrSyncInProgress
+	}
+	if err := spawn(func(ctx context.Context) {
+		s.runSponsors(ctx, mode, trigger)
+	}); err != nil {
+		s.end(&s.isSyncing)
+		return err
+	}
+	return nil
+}
+
+// runSponsors 在已持有同步标记的前提下执行同步，并在结束时释放标记
+func (s *SyncService) runSponsors(ctx context.Context, mode SyncMode, trigger string) {
+	for {
+		next, pending := s.runSponsorsOnce(ctx, mode, trigger)
+		if !pending {
+			return
+		}
+		mode, trigger = SyncModeFull, next
+	}
+}
 
+// runSponsorsOnce 执行一次同步。结束时若有排队的全量同步，则保留同步标记并返回其触发方式；
+// 否则释放标记。检查与释放在同一把锁内完成，避免期间排队的全量同步丢失；同步中途 panic 时同样释放标记
+func (s *SyncService) runSponsorsOnce(ctx context.Context, mode SyncMode, trigger string) (next string, pending bool) {
+	completed := false
 	defer func() {
 		s.mu.Lock()
+		defer s.mu.Unlock()
+		if completed && s.fullSyncPending && ctx.Err() == nil {
+			s.fullSyncPending = false
+			next, pending = s.pendingTrigger, true
+			return
+		}
 		s.isSyncing = false
-		s.mu.Unlock()
 	}()
 
+	s.syncSponsors(ctx, mode, trigger)
+	completed = true
+	return "", false
+}
+
+func (s *SyncService) syncSponsors(ctx context.Context, mode SyncMode, trigger string) {
 	startTime := time.Now()
-	log.Println("[定时任务] 开始同步赞助者数据...")
+	syncID := startTime.UnixMilli()
+	log.Printf("[定时任务] 开始同步赞助者数据（%s）...", mode)
+
+	tracker := s.beginRun(ctx, models.SyncJobSponsors, trigger, string(mode))
+	defer s.finishRun(ctx, tracker)
+	run := &tracker.run
+	publishEvents := s.shouldPublishEvents(ctx, &models.Sponsor{})
 
 	currentPage := 1
 	totalSynced := 0
+	unchangedRun := 0
 	hasMore := true
+	// 缺少 user 信息的记录无法标记为本次出现，存在时不清理，避免误删
+	unidentifiedSkipped := false
 
 	for hasMore {
-		data, err := s.client.QuerySponsor(currentPage, 100)
+		data, err := s.client.QuerySponsor(ctx, services.QuerySponsorParams{
+			Page:    currentPage,
+			PerPage: 100,
+		})
+		if ctx.Err() != nil {
+			log.Printf("[定时任务] 赞助者同步已取消（第 %d 页），共同步 %d 个赞助者", currentPage, totalSynced)
+			return
+		}
 		if err != nil {
-			log.Printf("[定时任务] 同步第 %d 页时出错: %v", currentPage, err)
+			logPageError("赞助者", currentPage, err)
+			tracker.fail(err)
 			break
 		}
+		run.PagesFetched++
 
 		if data == nil || len(data.List) == 0 {
 			hasMore = false
 			break
 		}
 
-		pageSynced := 0
+		records := make([]models.Sponsor, 0, len(data.List))
+		var skippedIDs []string
 		for _, sponsor := range data.List {
-			if sponsor.User.UserID == "" {
-				log.Println("[定时任务] 跳过无效的赞助者数据：缺少 user 信息")
+			record, ok := buildSponsorRecord(sponsor)
+			if !ok {
+				run.RowsSkipped++
+				if sponsor.User.UserID == "" {
+					unidentifiedSkipped = true
+				} else {
+					skippedIDs = append(skippedIDs, sponsor.User.UserID)
+				}
 				continue
 			}
+			record.LastSeenSyncID = syncID
+			records = append(records, record)
+		}
 
-			lastPayTime := pickFirstNonZero(
-				derefInt64(sponsor.LastPayTime),
-				sponsor.CreateTime,
-				derefInt64(sponsor.FirstPayTime),
-			)
-			firstPayTime := pickFirstNonZero(
-				derefInt64(sponsor.FirstPayTime),
-				sponsor.CreateTime,
-				lastPayTime,
-			)
-
-			if lastPayTime == 0 {
-				log.Printf("[定时任务] 跳过赞助者 %s：缺少时间字段", sponsor.User.UserID)
-				continue
-			}
+		existing, err := s.loadSponsors(ctx, records)
+		if err != nil {
+			log.Printf("[定时任务] 读取已有赞助者数据失败（第 %d 页）: %v", currentPage, err)
+			tracker.fail(err)
+			break
+		}
 
-			now := time.Now().Unix()
-			firstPayTimePtr := int64PtrOrNil(firstPayTime)
-			lastPayTimePtr := int64PtrOrNil(lastPayTime)
-			name := sponsor.User.Name
-			var avatarPtr *string
-			if sponsor.User.Avatar != "" {
-				avatar := sponsor.User.Avatar
-				avatarPtr = &avatar
+		pending := make([]models.Sponsor, 0, len(records))
+		for _, record := range records {
+			stored, found := existing[record.UserID]
+			unchanged := found && sponsorUnchanged(stored, &record)
+			if mode == SyncModeIncremental {
+				if unchanged {
+					run.RowsUnchanged++
+					unchangedRun++
+					if unchangedRun >= s.incrementalStopAfter {
+						hasMore = false
+						break
+					}
+					continue
+				}
+				unchangedRun = 0
 			}
+			pending = append(pending, record)
+		}
 
-			record := models.Sponsor{
-				UserID:       sponsor.User.UserID,
-				Name:         name,
-				Avatar:       avatarPtr,
-				AllSumAmount: sponsor.AllSumAmount,
-				CreateTime:   firstPayTime,
-				FirstPayTime: firstPayTimePtr,
-				LastPayTime:  lastPayTimePtr,
-				UpdatedAt:    now,
+		// 事件与数据在同一事务中写入，写入失败的记录不会留下事件
+		var pendingEvents map[string]models.Event
+		if publishEvents {
+			pendingEvents = make(map[string]models.Event, len(pending))
+			for _, record := range pending {
+				stored, found := existing[record.UserID]
+				if eventType := sponsorEventType(stored, found, &record); eventType != "" {
+					pendingEvents[record.UserID] = events.NewSponsorEvent(eventType, record)
+				}
 			}
+		}
 
-			err = s.db.Clauses(clause.OnConflict{
-				Columns: []clause.Column{{Name: "user_id"}},
-				DoUpdates: clause.Assignments(map[string]interface{}{
-					"name":           record.Name,
-					"avatar":         record.Avatar,
-					"all_sum_amount": record.AllSumAmount,
-					"create_time":    record.CreateTime,
-					"first_pay_time": gorm.Expr("COALESCE(?, first_pay_time)", record.FirstPayTime),
-					"last_pay_time":  record.LastPayTime,
-					"updated_at":     record.UpdatedAt,
-				}),
-			}).Create(&record).Error
-			if err != nil {
-				log.Printf("[定时任务] 处理赞助者时出错: %v", err)
+		failed := s.upsertSponsors(ctx, pending, pendingEvents, tracker)
+		if err := s.markSponsorsSeen(ctx, skippedIDs, syncID); err != nil && ctx.Err() == nil {
+			log.Printf("[定时任务] %v", err)
+			tracker.fail(err)
+		}
+		if ctx.Err() != nil {
+			log.Printf("[定时任务] 赞助者同步已取消（第 %d 页），共同步 %d 个赞助者", currentPage, totalSynced)
+			return
+		}
+
+		pageSynced := 0
+		pageChanged := 0
+		for i := range pending {
+			record := &pending[i]
+			if failed[record.UserID] {
 				continue
 			}
-
 			pageSynced++
+			stored, found := existing[record.UserID]
+			switch sponsorEventType(stored, found, record) {
+			case models.EventSponsorNew:
+				run.RowsInserted++
+				pageChanged++
+			case models.EventSponsorUpdated:
+				run.RowsUpdated++
+				pageChanged++
+			default:
+				run.RowsUnchanged++
+			}
+		}
+
+		// 全量同步会重写未变化的记录，只有公开数据确实变化时才需要失效缓存
+		if pageChanged > 0 {
+			s.invalidateCache(ctx)
 		}
 
 		totalSynced += pageSynced
+		s.reportProgress(tracker)
 		log.Printf("[定时任务] 已同步 %d/%d 个赞助者（第 %d 页）", pageSynced, len(data.List), currentPage)
 
-		if currentPage >= data.TotalPage || len(data.List) < 100 {
+		if !hasMore {
+			log.Printf("[定时任务] 连续 %d 个赞助者未变化，停止增量同步", unchangedRun)
+		} else if currentPage >= data.TotalPage || len(data.List) < 100 {
 			hasMore = false
 		} else {
 			currentPage++
-			time.Sleep(500 * time.Millisecond)
 		}
 	}
 
+	if mode == SyncModeFull && s.pruneEnabled {
+		if unidentifiedSkipped {
+			log.Println("[定时任务] 存在缺少 user 信息的赞助者数据，跳过清理已消失的赞助者")
+		} else if len(tracker.errors) == 0 {
+			removed, err := s.pruneSponsors(ctx, syncID)
+			if err != nil {
+				tracker.fail(err)
+			}
+			run.RowsRemoved = int(removed)
+		} else {
+			log.Println("[定时任务] 全量同步未完整完成，跳过清理已消失的赞助者")
+		}
+	}
+
+	// last_sync_time 即 /sponsor 的 Last-Modified，只在数据有变化时推进，
+	// 否则每次定时同步都会清空缓存并让条件请求失效
+	if run.RowsInserted+run.RowsUpdated+run.RowsRemoved > 0 {
+		s.updateLastSyncTime(ctx)
+	}
+
+	log.Printf("[定时任务] 同步完成，共同步 %d 个赞助者，耗时 %s", totalSynced, time.Since(startTime))
+}
+
+// updateLastSyncTime 记录赞助者数据最后一次发生变化的同步时间，并使接口缓存失效
+func (s *SyncService) updateLastSyncTime(ctx context.Context) {
 	syncTime := time.Now().Unix()
 	meta := models.SyncMetadata{
 		Key:       "last_sync_time",
 		Value:     strconv.FormatInt(syncTime, 10),
 		UpdatedAt: syncTime,
 	}
-	if err := s.db.Clauses(clause.OnConflict{
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
 		Columns: []clause.Column{{Name: "key"}},
 		DoUpdates: clause.Assignments(map[string]interface{}{
 			"value":      meta.Value,
@@ -153,44 +314,476 @@ func (s *SyncService) SyncSponsors() {
 		}),
 	}).Create(&meta).Error; err != nil {
 		log.Printf("[定时任务] 更新同步元数据失败: %v", err)
+		return
 	}
+	s.invalidateCache(ctx)
+}
 
-	log.Printf("[定时任务] 同步完成，共同步 %d 个赞助者，耗时 %s", totalSynced, time.Since(startTime))
+// invalidateCache 赞助者数据写入后使接口缓存失效；失败时只记录日志，旧数据最多保留一个 TTL
+func (s *SyncService) invalidateCache(ctx context.Context) {
+	if err := s.cache.Invalidate(ctx); err != nil {
+		log.Printf("[定时任务] 清除接口缓存失败: %v", err)
+	}
+}
+
+// shouldPublishEvents 表中已有数据时才为本次同步写入事件；首次导入的全部历史记录不推送，
+// 避免订阅者收到大量旧的赞助提醒
+func (s *SyncService) shouldPublishEvents(ctx context.Context, model interface{}) bool {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(model).Count(&count).Error; err != nil {
+		log.Printf("[定时任务] 统计已有数据失败，本次同步不推送事件: %v", err)
+		return false
+	}
+	return count > 0
+}
+
+// pruneSponsors 软删除本次全量同步未出现的赞助者。待删除比例超过阈值时放弃清理，
+// 避免接口异常返回不完整数据时清空赞助者墙。返回删除的条数。
+func (s *SyncService) pruneSponsors(ctx context.Context, syncID int64) (int64, error) {
+	var total int64
+	if err := s.db.WithContext(ctx).Model(&models.Sponsor{}).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("统计赞助者数量失败: %w", err)
+	}
+
+	var staleCount int64
+	if err := s.db.WithContext(ctx).Model(&models.Sponsor{}).Where("last_seen_sync_id < ?", syncID).Count(&staleCount).Error; err != nil {
+		return 0, fmt.Errorf("统计已消失的赞助者失败: %w", err)
+	}
+	if staleCount == 0 {
+		return 0, nil
+	}
+
+	if float64(staleCount) > float64(total)*s.pruneMaxRatio {
+		err := fmt.Errorf("待清理赞助者 %d/%d 超过阈值 %.0f%%，已放弃清理，请人工确认", staleCount, total, s.pruneMaxRatio*100)
+		log.Printf("[定时任务] %v", err)
+		return 0, err
+	}
+
+	result := s.db.WithContext(ctx).Where("last_seen_sync_id < ?", syncID).Delete(&models.Sponsor{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("清理已消失的赞助者失败: %w", result.Error)
+	}
+	log.Printf("[定时任务] 已清理 %d 个在爱发电已不存在的赞助者", result.RowsAffected)
+	return result.RowsAffected, nil
+}
+
+// sponsorEventType 按库中原有记录判断本次写入对应的事件类型，公开数据未变化时返回空字符串
+func sponsorEventType(stored models.Sponsor, found bool, record *models.Sponsor) string {
+	switch {
+	case !found:
+		return models.EventSponsorNew
+	case sponsorUnchanged(stored, record):
+		return ""
+	default:
+		return models.EventSponsorUpdated
+	}
+}
+
+// markSponsorsSeen 将因数据无效而跳过的赞助者标记为本次同步出现过，全量同步清理时不会删除仍在爱发电存在的记录
+func (s *SyncService) markSponsorsSeen(ctx context.Context, userIDs []string, syncID int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	if err := s.db.WithContext(ctx).Model(&models.Sponsor{}).
+		Where("user_id IN ?", userIDs).
+		UpdateColumn("last_seen_sync_id", syncID).Error; err != nil {
+		return fmt.Errorf("标记跳过的赞助者失败: %w", err)
+	}
+	return nil
+}
+
+// upsertSponsors 在一个事务内批量写入本页记录及其事件（按 user_id 对应，可为空）；
+// 批量写入失败时回退为逐条写入，以定位并跳过出错的记录。返回写入失败的 user_id 集合。
+func (s *SyncService) upsertSponsors(ctx context.Context, records []models.Sponsor, pendingEvents map[string]models.Event, tracker *runTracker) map[string]bool {
+	failed := make(map[string]bool)
+	if len(records) == 0 {
+		return failed
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(sponsorUpsertClause(tx)).Create(&records).Error; err != nil {
+			return err
+		}
+		var pageEvents []models.Event
+		for _, record := range records {
+			if event, ok := pendingEvents[record.UserID]; ok {
+				pageEvents = append(pageEvents, event)
+			}
+		}
+		return events.Publish(ctx, tx, pageEvents...)
+	})
+	if err == nil {
+		return failed
+	}
+	if ctx.Err() != nil {
+		return failed
+	}
+	log.Printf("[定时任务] 批量写入赞助者失败，改为逐条写入: %v", err)
+
+	for i := range records {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(sponsorUpsertClause(tx)).Create(&records[i]).Error; err != nil {
+				return err
+			}
+			if event, ok := pendingEvents[records[i].UserID]; ok {
+				return events.Publish(ctx, tx, event)
+			}
+			return nil
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return failed
+			}
+			log.Printf("[定时任务] 处理赞助者 %s 时出错: %v", records[i].UserID, err)
+			tracker.fail(fmt.Errorf("写入赞助者 %s 失败: %w", records[i].UserID, err))
+			failed[records[i].UserID] = true
+		}
+	}
+	return failed
+}
+
+// sponsorUpsertClause 冲突时更新除 user_id 外的字段，first_pay_time 为空时保留库中原值，
+// 已被软删除的赞助者重新出现时恢复。引用待插入值的写法因数据库方言而异
+func sponsorUpsertClause(tx *gorm.DB) clause.OnConflict {
+	assignments := clause.AssignmentColumns([]string{
+		"name",
+		"avatar",
+		"all_sum_amount",
+		"create_time",
+		"last_pay_time",
+		"last_seen_sync_id",
+		"updated_at",
+	})
+	keepFirstPayTime := gorm.Expr("COALESCE(" +
+		db.ExcludedColumn(tx, "first_pay_time") + ", " +
+		db.TableColumn(tx, models.Sponsor{}.TableName(), "first_pay_time") + ")")
+	assignments = append(assignments,
+		clause.Assignment{
+			Column: clause.Column{Name: "first_pay_time"},
+			Value:  keepFirstPayTime,
+		},
+		clause.Assignment{
+			Column: clause.Column{Name: "deleted_at"},
+			Value:  gorm.Expr("NULL"),
+		},
+	)
+
+	return clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Set(assignments),
+	}
+}
+
+// loadSponsors 按 user_id 读取本页赞助者在库中的记录。已软删除的记录视为不存在，
+// 重新出现时会被写入并恢复。
+func (s *SyncService) loadSponsors(ctx context.Context, records []models.Sponsor) (map[string]models.Sponsor, error) {
+	existing := make(map[string]models.Sponsor, len(records))
+	if len(records) == 0 {
+		return existing, nil
+	}
+
+	userIDs := make([]string, 0, len(records))
+	for _, record := range records {
+		userIDs = append(userIDs, record.UserID)
+	}
+
+	var stored []models.Sponsor
+	if err := s.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+	for _, sponsor := range stored {
+		existing[sponsor.UserID] = sponsor
+	}
+	return existing, nil
+}
+
+// buildSponsorRecord 将接口数据转换为数据库记录，缺少 user、时间字段或金额无效时返回 false
+func buildSponsorRecord(sponsor services.SponsorItem) (models.Sponsor, bool) {
+	if sponsor.User.UserID == "" {
+		log.Println("[定时任务] 跳过无效的赞助者数据：缺少 user 信息")
+		return models.Sponsor{}, false
+	}
+
+	lastPayTime := pickFirstNonZero(
+		derefInt64(sponsor.LastPayTime),
+		sponsor.CreateTime,
+		derefInt64(sponsor.FirstPayTime),
+	)
+	firstPayTime := pickFirstNonZero(
+		derefInt64(sponsor.FirstPayTime),
+		sponsor.CreateTime,
+		lastPayTime,
+	)
+
+	if lastPayTime == 0 {
+		log.Printf("[定时任务] 跳过赞助者 %s：缺少时间字段", sponsor.User.UserID)
+		return models.Sponsor{}, false
+	}
+
+	allSumAmount, err := models.ParseMoney(sponsor.AllSumAmount)
+	if err != nil {
+		log.Printf("[定时任务] 跳过赞助者 %s：%v", sponsor.User.UserID, err)
+		return models.Sponsor{}, false
+	}
+
+	var avatarPtr *string
+	if sponsor.User.Avatar != "" {
+		avatar := sponsor.User.Avatar
+		avatarPtr = &avatar
+	}
+
+	return models.Sponsor{
+		UserID:       sponsor.User.UserID,
+		Name:         sponsor.User.Name,
+		Avatar:       avatarPtr,
+		AllSumAmount: allSumAmount,
+		CreateTime:   firstPayTime,
+		FirstPayTime: int64PtrOrNil(firstPayTime),
+		LastPayTime:  int64PtrOrNil(lastPayTime),
+		UpdatedAt:    time.Now().Unix(),
+	}, true
+}
+
+// sponsorUnchanged 以最近赞助时间和累计金额判断记录是否需要更新
+func sponsorUnchanged(stored models.Sponsor, record *models.Sponsor) bool {
+	return derefInt64(stored.LastPayTime) == derefInt64(record.LastPayTime) &&
+		stored.AllSumAmount == record.AllSumAmount
+}
+
+// SyncOrders 分页拉取 /query-order 并写入订单表，每个订单的 SKU 在事务内整体替换
+func (s *SyncService) SyncOrders(ctx context.Context, trigger string) {
+	if !s.tryBegin(&s.isSyncingOrders) {
+		log.Println("[定时任务] 上一次订单同步仍在进行中，跳过本次执行")
+		return
+	}
+	defer s.end(&s.isSyncingOrders)
+	s.syncOrders(ctx, trigger)
+}
+
+// StartOrders 在后台发起一次订单同步，已有同步在进行时返回 ErrSyncInProgress
+func (s *SyncService) StartOrders(trigger string, spawn func(job func(ctx context.Context)) error) error {
+	if !s.tryBegin(&s.isSyncingOrders) {
+		return ErrSyncInProgress
+	}
+	if err := spawn(func(ctx context.Context) {
+		defer s.end(&s.isSyncingOrders)
+		s.syncOrders(ctx, trigger)
+	}); err != nil {
+		s.end(&s.isSyncingOrders)
+		return err
+	}
+	return nil
+}
+
+func (s *SyncService) syncOrders(ctx context.Context, trigger string) {
+	startTime := time.Now()
+	log.Println("[定时任务] 开始同步订单数据...")
+
+	tracker := s.beginRun(ctx, models.SyncJobOrders, trigger, string(SyncModeFull))
+	defer s.finishRun(ctx, tracker)
+	run := &tracker.run
+	publishEvents := s.shouldPublishEvents(ctx, &models.Order{})
+
+	currentPage := 1
+	totalSynced := 0
+	hasMore := true
+
+	for hasMore {
+		data, err := s.client.QueryOrder(ctx, services.QueryOrderParams{
+			Page:    currentPage,
+			PerPage: 100,
+		})
+		if ctx.Err() != nil {
+			log.Printf("[定时任务] 订单同步已取消（第 %d 页），共同步 %d 个订单", currentPage, totalSynced)
+			return
+		}
+		if err != nil {
+			logPageError("订单", currentPage, err)
+			tracker.fail(err)
+			break
+		}
+		run.PagesFetched++
+
+		if data == nil || len(data.List) == 0 {
+			break
+		}
+
+		pageSynced := 0
+		for _, order := range data.List {
+			if order.OutTradeNo == "" {
+				log.Println("[定时任务] 跳过无效的订单数据：缺少订单号")
+				run.RowsSkipped++
+				continue
+			}
+
+			record, err := order.ToModel()
+			if err != nil {
+				log.Printf("[定时任务] 跳过无效的订单数据: %v", err)
+				run.RowsSkipped++
+				continue
+			}
+			created, err := services.SaveOrder(ctx, s.db, &record, publishEvents)
+			if err != nil {
+				if ctx.Err() != nil {
+					log.Printf("[定时任务] 订单同步已取消（第 %d 页），共同步 %d 个订单", currentPage, totalSynced+pageSynced)
+					return
+				}
+				log.Printf("[定时任务] 处理订单 %s 时出错: %v", order.OutTradeNo, err)
+				tracker.fail(fmt.Errorf("写入订单 %s 失败: %w", order.OutTradeNo, err))
+				continue
+			}
+
+			if created {
+				run.RowsInserted++
+			} else {
+				run.RowsUpdated++
+			}
+			pageSynced++
+		}
+
+		totalSynced += pageSynced
+		s.reportProgress(tracker)
+		log.Printf("[定时任务] 已同步 %d/%d 个订单（第 %d 页）", pageSynced, len(data.List), currentPage)
+
+		if currentPage >= data.TotalPage || len(data.List) < 100 {
+			hasMore = false
+		} else {
+			currentPage++
+		}
+	}
+
+	log.Printf("[定时任务] 订单同步完成，共同步 %d 个订单，耗时 %s", totalSynced, time.Since(startTime))
+}
+
+func (s *SyncService) tryBegin(flag *bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if *flag {
+		return false
+	}
+	*flag = true
+	return true
+}
+
+func (s *SyncService) end(flag *bool) {
+	s.mu.Lock()
+	*flag = false
+	s.mu.Unlock()
 }
 
 type Scheduler struct {
-	cron        *cron.Cron
-	syncCron    string
-	syncService *SyncService
+	cron          *cron.Cron
+	syncCron      string
+	fullSyncCron  string
+	orderSyncCron string
+	syncService   *SyncService
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+	mu            sync.Mutex
+	stopped       bool
 }
 
-func NewScheduler(cfg *config.Config, db *gorm.DB, client *services.AfdianClient) *Scheduler {
+func NewScheduler(cfg *config.Config, db *gorm.DB, client *services.AfdianClient, responseCache cache.Cache) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Scheduler{
-		cron:        cron.New(),
-		syncCron:    cfg.Cron.SyncCron,
-		syncService: NewSyncService(db, client),
+		cron:          cron.New(),
+		syncCron:      cfg.Cron.SyncCron,
+		fullSyncCron:  cfg.Cron.FullSyncCron,
+		orderSyncCron: cfg.Cron.OrderSyncCron,
+		syncService:   NewSyncService(cfg, db, client, responseCache),
+		ctx:           ctx,
+		cancel:        cancel,
 	}
 }
 
 func (s *Scheduler) Start() error {
 	if _, err := s.cron.AddFunc(s.syncCron, func() {
-		s.syncService.SyncSponsors()
+		s.syncService.SyncSponsors(s.ctx, SyncModeIncremental, models.SyncTriggerCron)
+	}); err != nil {
+		return err
+	}
+	if _, err := s.cron.AddFunc(s.fullSyncCron, func() {
+		s.syncService.SyncSponsors(s.ctx, SyncModeFull, models.SyncTriggerCron)
+	}); err != nil {
+		return err
+	}
+	if _, err := s.cron.AddFunc(s.orderSyncCron, func() {
+		s.syncService.SyncOrders(s.ctx, models.SyncTriggerCron)
 	}); err != nil {
 		return err
 	}
 
-	go s.syncService.SyncSponsors()
+	if err := s.goRun(func(ctx context.Context) {
+		s.syncService.SyncSponsors(ctx, SyncModeFull, models.SyncTriggerStartup)
+	}); err != nil {
+		return err
+	}
+	if err := s.goRun(func(ctx context.Context) {
+		s.syncService.SyncOrders(ctx, models.SyncTriggerStartup)
+	}); err != nil {
+		return err
+	}
 	s.cron.Start()
-	log.Printf("[定时任务] 定时任务已启动，Cron表达式: %s", s.syncCron)
+	log.Printf("[定时任务] 定时任务已启动，增量同步: %s，全量同步: %s，订单同步: %s", s.syncCron, s.fullSyncCron, s.orderSyncCron)
 	return nil
 }
 
+// SyncService 返回调度器使用的同步服务
+func (s *Scheduler) SyncService() *SyncService {
+	return s.syncService
+}
+
+// TriggerSponsors 手动发起一次赞助者同步，随调度器一起取消
+func (s *Scheduler) TriggerSponsors(mode SyncMode) error {
+	return s.syncService.StartSponsors(mode, models.SyncTriggerManual, s.goRun)
+}
+
+// TriggerOrders 手动发起一次订单同步，随调度器一起取消
+func (s *Scheduler) TriggerOrders() error {
+	return s.syncService.StartOrders(models.SyncTriggerManual, s.goRun)
+}
+
+// Stop 取消正在进行的同步并等待所有任务退出，之后手动触发的同步返回 ErrSchedulerStopped
 func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+
+	s.cancel()
 	ctx := s.cron.Stop()
 	<-ctx.Done()
+	s.wg.Wait()
 	log.Println("[定时任务] 定时任务已停止")
 }
 
+// goRun 在后台执行一次任务，Stop 会等待其退出。与 Stop 共用锁，
+// 保证 Stop 开始等待后不会再有新任务加入
+func (s *Scheduler) goRun(job func(ctx context.Context)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return ErrSchedulerStopped
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		job(s.ctx)
+	}()
+	return nil
+}
+
+// logPageError 按错误类别输出日志，凭据类错误无法通过重试恢复，需要人工处理
+func logPageError(job string, page int, err error) {
+	if errors.Is(err, services.ErrUnauthorized) || errors.Is(err, services.ErrSignatureInvalid) {
+		log.Printf("[定时任务] 同步%s第 %d 页时鉴权失败，请检查 AFDIAN_USER_ID/AFDIAN_API_TOKEN: %v", job, page, err)
+		return
+	}
+	log.Printf("[定时任务] 同步%s第 %d 页时出错: %v", job, page, err)
+}
+
 func pickFirstNonZero(values ...int64) int64 {
 	for _, value := range values {
 		if value > 0 {
@@ -1,9 +1,11 @@
 package db
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
 	"log"
+	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -11,7 +13,11 @@ import (
 	"afdianapi/internal/models"
 
 	mysqlDriverConfig "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	mysqlDriver "gorm.io/driver/mysql"
+	postgresDriver "gorm.io/driver/postgres"
+	sqliteDriver "gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -20,16 +26,17 @@ var (
 	once       sync.Once
 )
 
-func Init(cfg *config.Config) (*gorm.DB, error) {
+// Connect 建立数据库连接，不执行任何迁移
+func Connect(cfg *config.Config) (*gorm.DB, error) {
 	var initErr error
 	once.Do(func() {
-		dsn, err := buildDSN(cfg)
+		dialector, err := openDialector(cfg)
 		if err != nil {
 			initErr = err
 			return
 		}
 
-		db, err := gorm.Open(mysqlDriver.Open(dsn), &gorm.Config{})
+		db, err := gorm.Open(dialector, &gorm.Config{})
 		if err != nil {
 			initErr = fmt.Errorf("数据库连接失败: %w", err)
 			return
@@ -41,30 +48,74 @@ func Init(cfg *config.Config) (*gorm.DB, error) {
 			return
 		}
 
-		sqlDB.SetMaxOpenConns(cfg.Database.ConnectionLimit)
-		sqlDB.SetMaxIdleConns(cfg.Database.ConnectionLimit)
-		sqlDB.SetConnMaxLifetime(30 * time.Minute)
+		if cfg.Database.Driver == config.DBDriverSQLite {
+			// SQLite 同一时刻只允许一个写入者，且内存数据库随连接关闭而销毁，固定使用单个长连接
+			sqlDB.SetMaxOpenConns(1)
+			sqlDB.SetMaxIdleConns(1)
+			sqlDB.SetConnMaxLifetime(0)
+		} else {
+			sqlDB.SetMaxOpenConns(cfg.Database.ConnectionLimit)
+			sqlDB.SetMaxIdleConns(cfg.Database.ConnectionLimit)
+			sqlDB.SetConnMaxLifetime(30 * time.Minute)
+		}
 
 		if err := sqlDB.Ping(); err != nil {
 			initErr = fmt.Errorf("数据库连通性检查失败: %w", err)
 			return
 		}
 
+		dbInstance = db
+		if cfg.Database.Driver == config.DBDriverSQLite {
+			log.Printf("数据库连接成功: sqlite %s", cfg.Database.Path)
+		} else {
+			log.Printf("数据库连接成功: %s %s:%d/%s", cfg.Database.Driver, cfg.Database.Host, cfg.Database.Port, cfg.Database.Name)
+		}
+	})
+
+	return dbInstance, initErr
+}
+
+// Init 建立数据库连接并确认表结构就绪：开启 DB_AUTO_MIGRATE 时直接按模型 AutoMigrate（仅限开发环境）；
+// SQLite 内存数据库自动执行全部版本化迁移；否则要求全部版本化迁移均已执行，存在未执行的迁移时返回错误
+func Init(cfg *config.Config) (*gorm.DB, error) {
+	db, err := Connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Database.AutoMigrate {
+		log.Println("[迁移] 已开启 DB_AUTO_MIGRATE，按模型自动迁移，请勿在生产环境使用")
 		if err := db.AutoMigrate(
 			&models.Order{},
 			&models.OrderSku{},
 			&models.Sponsor{},
 			&models.SyncMetadata{},
+			&models.SyncRun{},
+			&models.APIKey{},
+			&models.Event{},
 		); err != nil {
-			initErr = fmt.Errorf("数据库迁移失败: %w", err)
-			return
+			return nil, fmt.Errorf("数据库迁移失败: %w", err)
 		}
+		return db, nil
+	}
 
-		dbInstance = db
-		log.Printf("数据库连接成功: %s:%d/%s", cfg.Database.Host, cfg.Database.Port, cfg.Database.Name)
-	})
+	// 内存数据库每次启动都是空库，且无法由单独的 migrate up 进程访问
+	if cfg.Database.Driver == config.DBDriverSQLite && cfg.Database.Path == ":memory:" {
+		if _, err := MigrateUp(context.Background(), db, 0, false); err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
 
-	return dbInstance, initErr
+	pending, err := PendingMigrations(context.Background(), db)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("存在 %d 个未执行的数据库迁移（最早为 %d_%s），请先执行 migrate up",
+			len(pending), pending[0].Version, pending[0].Name)
+	}
+	return db, nil
 }
 
 func GetDB() (*gorm.DB, error) {
@@ -85,6 +136,26 @@ func Close() error {
 	return sqlDB.Close()
 }
 
+// openDialector 按 DB_DRIVER 构建对应数据库的 gorm 方言
+func openDialector(cfg *config.Config) (gorm.Dialector, error) {
+	switch cfg.Database.Driver {
+	case config.DBDriverSQLite:
+		return sqliteDriver.Open(buildSQLiteDSN(cfg)), nil
+	case config.DBDriverPostgres:
+		connConfig, err := buildPostgresConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return postgresDriver.New(postgresDriver.Config{Conn: stdlib.OpenDB(*connConfig)}), nil
+	default:
+		dsn, err := buildDSN(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return mysqlDriver.Open(dsn), nil
+	}
+}
+
 func buildDSN(cfg *config.Config) (string, error) {
 	mysqlCfg := mysqlDriverConfig.Config{
 		User:                 cfg.Database.User,
@@ -101,11 +172,13 @@ func buildDSN(cfg *config.Config) (string, error) {
 		},
 	}
 
-	if cfg.Database.SSL {
+	tlsConfig, err := buildTLSConfig(cfg.Database)
+	if err != nil {
+		return "", err
+	}
+	if tlsConfig != nil {
 		tlsConfigName := "afdianapi_tls"
-		if err := mysqlDriverConfig.RegisterTLSConfig(tlsConfigName, &tls.Config{
-			InsecureSkipVerify: true,
-		}); err != nil {
+		if err := mysqlDriverConfig.RegisterTLSConfig(tlsConfigName, tlsConfig); err != nil {
 			return "", fmt.Errorf("注册 TLS 配置失败: %w", err)
 		}
 		mysqlCfg.TLSConfig = tlsConfigName
@@ -113,3 +186,41 @@ func buildDSN(cfg *config.Config) (string, error) {
 
 	return mysqlCfg.FormatDSN(), nil
 }
+
+// buildPostgresConfig 构建 pgx 连接配置，TLS 由 buildTLSConfig 统一生成，不使用 libpq 的 sslmode 参数
+func buildPostgresConfig(cfg *config.Config) (*pgx.ConnConfig, error) {
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(cfg.Database.User, cfg.Database.Password),
+		Host:   fmt.Sprintf("%s:%d", cfg.Database.Host, cfg.Database.Port),
+		Path:   "/" + cfg.Database.Name,
+		RawQuery: url.Values{
+			"sslmode":         {"disable"},
+			"connect_timeout": {strconv.Itoa(cfg.Database.ConnectTimeout)},
+		}.Encode(),
+	}
+
+	connConfig, err := pgx.ParseConfig(dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("解析 PostgreSQL 连接参数失败: %w", err)
+	}
+
+	tlsConfig, err := buildTLSConfig(cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	connConfig.TLSConfig = tlsConfig
+	return connConfig, nil
+}
+
+func buildSQLiteDSN(cfg *config.Config) string {
+	params := url.Values{
+		"_foreign_keys": {"on"},
+		"_busy_timeout": {strconv.Itoa(cfg.Database.ConnectTimeout * 1000)},
+	}
+	if cfg.Database.Path == ":memory:" {
+		return "file::memory:?" + params.Encode()
+	}
+	params.Set("_journal_mode", "WAL")
+	return "file:" + cfg.Database.Path + "?" + params.Encode()
+}
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ExcludedColumn 返回 upsert 冲突更新中“本次待插入值”的列引用：
+// MySQL 为 VALUES(`col`)，PostgreSQL 与 SQLite 为 excluded."col"
+func ExcludedColumn(tx *gorm.DB, column string) string {
+	if tx.Dialector.Name() == "mysql" {
+		return "VALUES(" + quote(tx, column) + ")"
+	}
+	return "excluded." + quote(tx, column)
+}
+
+// TableColumn 返回带表名限定的列引用，用于 upsert 中引用库中原值
+func TableColumn(tx *gorm.DB, table string, column string) string {
+	return quote(tx, table) + "." + quote(tx, column)
+}
+
+func quote(tx *gorm.DB, name string) string {
+	var builder strings.Builder
+	tx.Dialector.QuoteTo(&builder, name)
+	return builder.String()
+}
@@ -0,0 +1,262 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration 一个版本化的数据库结构变更，版本号递增且发布后不可修改
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// MigrationStatus 迁移的执行状态
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *int64
+}
+
+// schemaMigration 已执行迁移的记录
+type schemaMigration struct {
+	Version   int    `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string `gorm:"column:name;size:255"`
+	AppliedAt int64  `gorm:"column:applied_at"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// sortedMigrations 返回按版本号升序排列的迁移列表
+func sortedMigrations() []Migration {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	return sorted
+}
+
+func ensureMigrationTable(db *gorm.DB) error {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return fmt.Errorf("创建 schema_migrations 表失败: %w", err)
+	}
+	return nil
+}
+
+// loadAppliedMigrations 读取已执行的迁移。记录表不存在时视为尚未执行任何迁移，不创建该表，
+// 以保证 status 与 -dry-run 不修改数据库
+func loadAppliedMigrations(db *gorm.DB) (map[int]schemaMigration, error) {
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return map[int]schemaMigration{}, nil
+	}
+
+	var records []schemaMigration
+	if err := db.Order("version asc").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("读取迁移记录失败: %w", err)
+	}
+
+	applied := make(map[int]schemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// MigrationStatuses 返回全部已知迁移的执行状态
+func MigrationStatuses(ctx context.Context, db *gorm.DB) ([]MigrationStatus, error) {
+	db = db.WithContext(ctx)
+	applied, err := loadAppliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range sortedMigrations() {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// PendingMigrations 返回尚未执行的迁移，按版本号升序
+func PendingMigrations(ctx context.Context, db *gorm.DB) ([]Migration, error) {
+	db = db.WithContext(ctx)
+	applied, err := loadAppliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range sortedMigrations() {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// MigrateUp 依次执行未执行的迁移，target 大于 0 时只执行到该版本（含）；
+// dryRun 时只返回将要执行的迁移，不修改数据库，语句可通过 PreviewUp 获取
+func MigrateUp(ctx context.Context, db *gorm.DB, target int, dryRun bool) ([]Migration, error) {
+	pending, err := PendingMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []Migration
+	for _, migration := range pending {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		plan = append(plan, migration)
+	}
+	if dryRun || len(plan) == 0 {
+		return plan, nil
+	}
+
+	if err := ensureMigrationTable(db.WithContext(ctx)); err != nil {
+		return nil, err
+	}
+	for i, migration := range plan {
+		if err := applyMigration(db.WithContext(ctx), migration); err != nil {
+			return plan[:i], err
+		}
+	}
+	return plan, nil
+}
+
+// MigrateDown 按版本号倒序回滚最近执行的 steps 个迁移；
+// dryRun 时只返回将要回滚的迁移，不修改数据库，语句可通过 PreviewDown 获取
+func MigrateDown(ctx context.Context, db *gorm.DB, steps int, dryRun bool) ([]Migration, error) {
+	applied, err := loadAppliedMigrations(db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	known := sortedMigrations()
+	var plan []Migration
+	for i := len(known) - 1; i >= 0 && len(plan) < steps; i-- {
+		if _, ok := applied[known[i].Version]; ok {
+			plan = append(plan, known[i])
+		}
+	}
+	if dryRun {
+		return plan, nil
+	}
+
+	for i, migration := range plan {
+		if err := revertMigration(db.WithContext(ctx), migration); err != nil {
+			return plan[:i], err
+		}
+	}
+	return plan, nil
+}
+
+// PreviewUp 返回执行迁移时将要运行的写入语句，不修改数据库。
+// 语句基于当前库结构生成，预览多个迁移时不包含前面迁移产生的变化
+func PreviewUp(ctx context.Context, db *gorm.DB, migration Migration) ([]string, error) {
+	return previewMigration(ctx, db, migration.Up)
+}
+
+// PreviewDown 返回回滚迁移时将要运行的写入语句，不修改数据库
+func PreviewDown(ctx context.Context, db *gorm.DB, migration Migration) ([]string, error) {
+	if migration.Down == nil {
+		return nil, fmt.Errorf("迁移 %d_%s 不支持回滚", migration.Version, migration.Name)
+	}
+	return previewMigration(ctx, db, migration.Down)
+}
+
+func previewMigration(ctx context.Context, db *gorm.DB, run func(tx *gorm.DB) error) ([]string, error) {
+	tx := db.WithContext(ctx).Session(&gorm.Session{SkipDefaultTransaction: true})
+	pool := &dryRunConnPool{ConnPool: tx.Statement.ConnPool, dialector: tx.Dialector}
+	tx.Statement.ConnPool = pool
+	if err := run(tx); err != nil {
+		return pool.statements, err
+	}
+	return pool.statements, nil
+}
+
+// dryRunConnPool 查询照常执行，以便迁移读取当前库结构；写入语句只记录不执行
+type dryRunConnPool struct {
+	gorm.ConnPool
+	dialector  gorm.Dialector
+	statements []string
+}
+
+func (p *dryRunConnPool) ExecContext(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.statements = append(p.statements, p.dialector.Explain(query, args...))
+	return driver.RowsAffected(0), nil
+}
+
+// BeginTx 迁移内部开启的事务同样只记录语句
+func (p *dryRunConnPool) BeginTx(_ context.Context, _ *sql.TxOptions) (gorm.ConnPool, error) {
+	return &dryRunTx{p}, nil
+}
+
+type dryRunTx struct {
+	*dryRunConnPool
+}
+
+func (*dryRunTx) Commit() error {
+	return nil
+}
+
+func (*dryRunTx) Rollback() error {
+	return nil
+}
+
+// applyMigration 在事务中执行迁移并写入版本记录；
+// MySQL 的 DDL 会隐式提交，失败时可能需要手动清理
+func applyMigration(db *gorm.DB, migration Migration) error {
+	log.Printf("[迁移] 执行 %d_%s", migration.Version, migration.Name)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&schemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now().Unix(),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("执行迁移 %d_%s 失败: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+func revertMigration(db *gorm.DB, migration Migration) error {
+	if migration.Down == nil {
+		return fmt.Errorf("迁移 %d_%s 不支持回滚", migration.Version, migration.Name)
+	}
+
+	log.Printf("[迁移] 回滚 %d_%s", migration.Version, migration.Name)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Down(tx); err != nil {
+			return err
+		}
+		return tx.Where("version = ?", migration.Version).Delete(&schemaMigration{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("回滚迁移 %d_%s 失败: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,404 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// migrations 全部版本化迁移。已发布的迁移不可修改，结构变更请追加新版本；
+// 迁移内使用各自冻结的结构体快照，不引用 internal/models，避免模型演进改变历史迁移
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "baseline",
+		Up:      migrateBaselineUp,
+		Down:    migrateBaselineDown,
+	},
+	{
+		Version: 2,
+		Name:    "events",
+		Up:      migrateEventsUp,
+		Down:    migrateEventsDown,
+	},
+	{
+		Version: 3,
+		Name:    "events_seq",
+		Up:      migrateEventsSeqUp,
+		Down:    migrateEventsSeqDown,
+	},
+	{
+		Version: 4,
+		Name:    "sponsors_last_pay_time_not_null",
+		Up:      migrateSponsorsPayTimeUp,
+		Down:    migrateSponsorsPayTimeDown,
+	},
+}
+
+// 版本 1：基线结构，对应引入版本化迁移前 AutoMigrate 维护的全部表。
+// 对已由 AutoMigrate 建表的旧库执行时只会补齐差异，可安全重复执行
+
+type baselineOrder struct {
+	OutTradeNo     string  `gorm:"column:out_trade_no;primaryKey;size:255"`
+	CustomOrderID  *string `gorm:"column:custom_order_id;size:255"`
+	UserID         string  `gorm:"column:user_id;size:255;index:idx_orders_user_id"`
+	UserPrivateID  *string `gorm:"column:user_private_id;size:255"`
+	PlanID         *string `gorm:"column:plan_id;size:255;index:idx_orders_plan_id"`
+	Month          int     `gorm:"column:month;default:1"`
+	TotalAmount    string  `gorm:"column:total_amount;type:decimal(20,2)"`
+	ShowAmount     string  `gorm:"column:show_amount;type:decimal(20,2)"`
+	Status         int     `gorm:"column:status;index:idx_orders_status"`
+	Remark         *string `gorm:"column:remark;type:text"`
+	RedeemID       *string `gorm:"column:redeem_id;size:255"`
+	ProductType    int     `gorm:"column:product_type;default:0"`
+	Discount       string  `gorm:"column:discount;type:decimal(20,2);default:0.00"`
+	AddressPerson  *string `gorm:"column:address_person;size:255"`
+	AddressPhone   *string `gorm:"column:address_phone;size:255"`
+	AddressAddress *string `gorm:"column:address_address;type:text"`
+	CreatedAt      int64   `gorm:"column:created_at;index:idx_orders_created_at"`
+	UpdatedAt      int64   `gorm:"column:updated_at"`
+	// 字段名需与 models.Order 一致，外键约束名 fk_orders_skus 由此生成
+	Skus []baselineOrderSku `gorm:"foreignKey:OutTradeNo;references:OutTradeNo;constraint:OnDelete:CASCADE"`
+}
+
+func (baselineOrder) TableName() string {
+	return "orders"
+}
+
+type baselineOrderSku struct {
+	ID         uint    `gorm:"column:id;primaryKey;autoIncrement"`
+	OutTradeNo string  `gorm:"column:out_trade_no;size:255;index:idx_order_skus_out_trade_no"`
+	SkuID      string  `gorm:"column:sku_id;size:255;index:idx_order_skus_sku_id"`
+	Count      int     `gorm:"column:count;default:1"`
+	Name       *string `gorm:"column:name;size:255"`
+	AlbumID    *string `gorm:"column:album_id;size:255"`
+	Pic        *string `gorm:"column:pic;type:text"`
+}
+
+func (baselineOrderSku) TableName() string {
+	return "order_skus"
+}
+
+type baselineSponsor struct {
+	UserID         string         `gorm:"column:user_id;primaryKey;size:255"`
+	Name           string         `gorm:"column:name;size:255"`
+	Avatar         *string        `gorm:"column:avatar;type:text"`
+	AllSumAmount   string         `gorm:"column:all_sum_amount;type:decimal(20,2);default:0.00"`
+	CreateTime     int64          `gorm:"column:create_time;index:idx_sponsors_create_time"`
+	FirstPayTime   *int64         `gorm:"column:first_pay_time"`
+	LastPayTime    *int64         `gorm:"column:last_pay_time;index:idx_sponsors_last_pay_time"`
+	LastSeenSyncID int64          `gorm:"column:last_seen_sync_id;default:0;index:idx_sponsors_last_seen_sync_id"`
+	UpdatedAt      int64          `gorm:"column:updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"column:deleted_at;index:idx_sponsors_deleted_at"`
+}
+
+func (baselineSponsor) TableName() string {
+	return "sponsors"
+}
+
+type baselineSyncMetadata struct {
+	ID        uint   `gorm:"column:id;primaryKey;autoIncrement"`
+	Key       string `gorm:"column:key;size:255;uniqueIndex:idx_sync_metadata_key"`
+	Value     string `gorm:"column:value;type:text"`
+	UpdatedAt int64  `gorm:"column:updated_at"`
+}
+
+func (baselineSyncMetadata) TableName() string {
+	return "sync_metadata"
+}
+
+type baselineSyncRun struct {
+	ID            uint    `gorm:"column:id;primaryKey;autoIncrement"`
+	Job           string  `gorm:"column:job;size:50;index:idx_sync_runs_job_started_at,priority:1"`
+	Trigger       string  `gorm:"column:triggered_by;size:20"`
+	Mode          string  `gorm:"column:mode;size:20"`
+	Status        string  `gorm:"column:status;size:20;index:idx_sync_runs_status"`
+	StartedAt     int64   `gorm:"column:started_at;index:idx_sync_runs_job_started_at,priority:2"`
+	FinishedAt    *int64  `gorm:"column:finished_at"`
+	PagesFetched  int     `gorm:"column:pages_fetched;default:0"`
+	RowsInserted  int     `gorm:"column:rows_inserted;default:0"`
+	RowsUpdated   int     `gorm:"column:rows_updated;default:0"`
+	RowsUnchanged int     `gorm:"column:rows_unchanged;default:0"`
+	RowsSkipped   int     `gorm:"column:rows_skipped;default:0"`
+	RowsRemoved   int     `gorm:"column:rows_removed;default:0"`
+	Error         *string `gorm:"column:error;type:text"`
+}
+
+func (baselineSyncRun) TableName() string {
+	return "sync_runs"
+}
+
+type baselineAPIKey struct {
+	ID         uint   `gorm:"column:id;primaryKey;autoIncrement"`
+	Name       string `gorm:"column:name;size:255"`
+	Prefix     string `gorm:"column:prefix;size:16"`
+	KeyHash    string `gorm:"column:key_hash;size:64;uniqueIndex:idx_api_keys_key_hash"`
+	Scopes     string `gorm:"column:scopes;size:255"`
+	CreatedAt  int64  `gorm:"column:created_at"`
+	LastUsedAt *int64 `gorm:"column:last_used_at"`
+	RevokedAt  *int64 `gorm:"column:revoked_at"`
+}
+
+func (baselineAPIKey) TableName() string {
+	return "api_keys"
+}
+
+// baselineAmountColumns 引入版本化迁移前以 varchar(50) 存储的金额列，
+// 基线迁移将其转换为 DECIMAL(20,2)，defaultValue 为转换后的列默认值
+var baselineAmountColumns = []struct {
+	model        interface{}
+	table        string
+	column       string
+	defaultValue string
+}{
+	{&baselineOrder{}, "orders", "total_amount", ""},
+	{&baselineOrder{}, "orders", "show_amount", ""},
+	{&baselineOrder{}, "orders", "discount", "0.00"},
+	{&baselineSponsor{}, "sponsors", "all_sum_amount", "0.00"},
+}
+
+// amountPattern 可转换为 DECIMAL 的金额文本
+const amountPattern = "^-?[0-9]+([.][0-9]+)?$"
+
+func migrateBaselineUp(tx *gorm.DB) error {
+	for _, column := range baselineAmountColumns {
+		if err := convertLegacyAmountColumn(tx, column.model, column.table, column.column, column.defaultValue); err != nil {
+			return err
+		}
+	}
+
+	return tx.AutoMigrate(
+		&baselineOrder{},
+		&baselineOrderSku{},
+		&baselineSponsor{},
+		&baselineSyncMetadata{},
+		&baselineSyncRun{},
+		&baselineAPIKey{},
+	)
+}
+
+// convertLegacyAmountColumn 将旧库中字符串类型的金额列显式转换为 DECIMAL(20,2)，列已是数值类型或不存在时跳过。
+// 先校验全部非空值均为合法金额，否则中止迁移且不修改数据；再去除首尾空白、将空值置为 0，最后按方言修改列类型。
+// 不依赖 AutoMigrate 的隐式 ALTER：PostgreSQL 缺少 USING 子句时无法转换，MySQL 对非法值的处理取决于 sql_mode
+func convertLegacyAmountColumn(tx *gorm.DB, model interface{}, table string, column string, defaultValue string) error {
+	if !tx.Migrator().HasTable(table) {
+		return nil
+	}
+	columnTypes, err := tx.Migrator().ColumnTypes(table)
+	if err != nil {
+		return fmt.Errorf("读取 %s 表结构失败: %w", table, err)
+	}
+	legacy := false
+	for _, columnType := range columnTypes {
+		if columnType.Name() != column {
+			continue
+		}
+		typeName := strings.ToLower(columnType.DatabaseTypeName())
+		legacy = !strings.Contains(typeName, "decimal") && !strings.Contains(typeName, "numeric")
+	}
+	if !legacy {
+		return nil
+	}
+
+	quotedTable := quote(tx, table)
+	quotedColumn := quote(tx, column)
+	dialect := tx.Dialector.Name()
+
+	var invalidCondition string
+	switch dialect {
+	case "mysql":
+		invalidCondition = "TRIM(" + quotedColumn + ") NOT REGEXP '" + amountPattern + "'"
+	case "postgres":
+		invalidCondition = "TRIM(" + quotedColumn + ") !~ '" + amountPattern + "'"
+	case "sqlite":
+		// SQLite 默认没有 REGEXP，用 GLOB 排除含非数字字符或不以数字、负号开头的值
+		invalidCondition = "(TRIM(" + quotedColumn + ") GLOB '*[^0-9.-]*' OR NOT (TRIM(" + quotedColumn + ") GLOB '[0-9]*' OR TRIM(" + quotedColumn + ") GLOB '-[0-9]*'))"
+	default:
+		return fmt.Errorf("不支持转换 %s 数据库中的金额列", dialect)
+	}
+
+	var invalid int64
+	if err := tx.Table(table).
+		Where(quotedColumn + " IS NOT NULL AND TRIM(" + quotedColumn + ") <> ''").
+		Where(invalidCondition).
+		Count(&invalid).Error; err != nil {
+		return fmt.Errorf("检查 %s.%s 的金额数据失败: %w", table, column, err)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%s.%s 中有 %d 条无法解析为金额的值，请手动修正后重新执行迁移", table, column, invalid)
+	}
+
+	statements := []string{
+		"UPDATE " + quotedTable + " SET " + quotedColumn + " = TRIM(" + quotedColumn + ") WHERE " + quotedColumn + " <> TRIM(" + quotedColumn + ")",
+		"UPDATE " + quotedTable + " SET " + quotedColumn + " = '0' WHERE " + quotedColumn + " IS NULL OR " + quotedColumn + " = ''",
+	}
+	switch dialect {
+	case "mysql":
+		alter := "ALTER TABLE " + quotedTable + " MODIFY COLUMN " + quotedColumn + " DECIMAL(20,2)"
+		if defaultValue != "" {
+			alter += " DEFAULT " + defaultValue
+		}
+		statements = append(statements, alter)
+	case "postgres":
+		// 旧列的字符串默认值无法随类型转换，需先删除再重新设置
+		statements = append(statements,
+			"ALTER TABLE "+quotedTable+" ALTER COLUMN "+quotedColumn+" DROP DEFAULT",
+			"ALTER TABLE "+quotedTable+" ALTER COLUMN "+quotedColumn+" TYPE DECIMAL(20,2) USING "+quotedColumn+"::DECIMAL(20,2)",
+		)
+		if defaultValue != "" {
+			statements = append(statements, "ALTER TABLE "+quotedTable+" ALTER COLUMN "+quotedColumn+" SET DEFAULT "+defaultValue)
+		}
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return fmt.Errorf("转换 %s.%s 为 DECIMAL 失败: %w", table, column, err)
+		}
+	}
+
+	// SQLite 不支持修改列类型，由 gorm 按模型重建表，此时数据已规范化
+	if dialect == "sqlite" {
+		if err := tx.Migrator().AlterColumn(model, column); err != nil {
+			return fmt.Errorf("转换 %s.%s 为 DECIMAL 失败: %w", table, column, err)
+		}
+	}
+	return nil
+}
+
+func migrateBaselineDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(
+		&baselineAPIKey{},
+		&baselineSyncRun{},
+		&baselineSyncMetadata{},
+		&baselineSponsor{},
+		&baselineOrderSku{},
+		&baselineOrder{},
+	)
+}
+
+// 版本 2：事件日志，供 GET /events 推送与断线续传
+
+type eventsEvent struct {
+	ID        uint   `gorm:"column:id;primaryKey;autoIncrement"`
+	Type      string `gorm:"column:type;size:50"`
+	Data      string `gorm:"column:data;type:text"`
+	CreatedAt int64  `gorm:"column:created_at;index:idx_events_created_at"`
+}
+
+func (eventsEvent) TableName() string {
+	return "events"
+}
+
+func migrateEventsUp(tx *gorm.DB) error {
+	return tx.AutoMigrate(&eventsEvent{})
+}
+
+func migrateEventsDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&eventsEvent{})
+}
+
+// 版本 3：事件按可见顺序编号。已有事件均已提交，直接以 ID 作为序号
+
+type eventsSeqEvent struct {
+	ID        uint    `gorm:"column:id;primaryKey;autoIncrement"`
+	Type      string  `gorm:"column:type;size:50"`
+	Data      string  `gorm:"column:data;type:text"`
+	CreatedAt int64   `gorm:"column:created_at;index:idx_events_created_at"`
+	Seq       *uint64 `gorm:"column:seq;uniqueIndex:idx_events_seq"`
+}
+
+func (eventsSeqEvent) TableName() string {
+	return "events"
+}
+
+func migrateEventsSeqUp(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn(&eventsSeqEvent{}, "Seq") {
+		if err := tx.Migrator().AddColumn(&eventsSeqEvent{}, "Seq"); err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(&eventsSeqEvent{}).Where("seq IS NULL").Update("seq", gorm.Expr("id")).Error; err != nil {
+		return err
+	}
+	if tx.Migrator().HasIndex(&eventsSeqEvent{}, "idx_events_seq") {
+		return nil
+	}
+	return tx.Migrator().CreateIndex(&eventsSeqEvent{}, "idx_events_seq")
+}
+
+func migrateEventsSeqDown(tx *gorm.DB) error {
+	if err := tx.Migrator().DropIndex(&eventsSeqEvent{}, "idx_events_seq"); err != nil {
+		return err
+	}
+	return tx.Migrator().DropColumn(&eventsSeqEvent{}, "Seq")
+}
+
+// 版本 4：sponsors.last_pay_time 改为 NOT NULL，排序与游标分页可直接使用 idx_sponsors_last_pay_time。
+// 同步只写入有付款时间的赞助者，旧库中的空值按 0 处理
+
+type payTimeSponsor struct {
+	UserID         string         `gorm:"column:user_id;primaryKey;size:255"`
+	Name           string         `gorm:"column:name;size:255"`
+	Avatar         *string        `gorm:"column:avatar;type:text"`
+	AllSumAmount   string         `gorm:"column:all_sum_amount;type:decimal(20,2);default:0.00"`
+	CreateTime     int64          `gorm:"column:create_time;index:idx_sponsors_create_time"`
+	FirstPayTime   *int64         `gorm:"column:first_pay_time"`
+	LastPayTime    int64          `gorm:"column:last_pay_time;not null;index:idx_sponsors_last_pay_time"`
+	LastSeenSyncID int64          `gorm:"column:last_seen_sync_id;default:0;index:idx_sponsors_last_seen_sync_id"`
+	UpdatedAt      int64          `gorm:"column:updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"column:deleted_at;index:idx_sponsors_deleted_at"`
+}
+
+func (payTimeSponsor) TableName() string {
+	return "sponsors"
+}
+
+func migrateSponsorsPayTimeUp(tx *gorm.DB) error {
+	if err := tx.Table("sponsors").Where("last_pay_time IS NULL").Update("last_pay_time", 0).Error; err != nil {
+		return err
+	}
+	return alterSponsorsPayTime(tx, &payTimeSponsor{}, true)
+}
+
+func migrateSponsorsPayTimeDown(tx *gorm.DB) error {
+	return alterSponsorsPayTime(tx, &baselineSponsor{}, false)
+}
+
+// alterSponsorsPayTime 修改 last_pay_time 的可空性。SQLite 不支持修改列，由 gorm 按模型重建表，
+// 重建会丢失索引，再由 AutoMigrate 补齐
+func alterSponsorsPayTime(tx *gorm.DB, model interface{}, notNull bool) error {
+	quotedTable := quote(tx, "sponsors")
+	quotedColumn := quote(tx, "last_pay_time")
+
+	var statement string
+	switch dialect := tx.Dialector.Name(); dialect {
+	case "mysql":
+		nullability := "NULL"
+		if notNull {
+			nullability = "NOT NULL"
+		}
+		statement = "ALTER TABLE " + quotedTable + " MODIFY COLUMN " + quotedColumn + " BIGINT " + nullability
+	case "postgres":
+		action := "DROP NOT NULL"
+		if notNull {
+			action = "SET NOT NULL"
+		}
+		statement = "ALTER TABLE " + quotedTable + " ALTER COLUMN " + quotedColumn + " " + action
+	case "sqlite":
+		// 重建依赖现有的建表语句；预览尚未执行基线迁移的库时表不存在，无需重建
+		if !tx.Migrator().HasTable(model) {
+			return nil
+		}
+		if err := tx.Migrator().AlterColumn(model, "LastPayTime"); err != nil {
+			return fmt.Errorf("修改 sponsors.last_pay_time 失败: %w", err)
+		}
+		return tx.AutoMigrate(model)
+	default:
+		return fmt.Errorf("不支持修改 %s 数据库中的列", dialect)
+	}
+
+	if err := tx.Exec(statement).Error; err != nil {
+		return fmt.Errorf("修改 sponsors.last_pay_time 失败: %w", err)
+	}
+	return nil
+}
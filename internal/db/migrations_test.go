@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"afdianapi/internal/models"
+
+	sqliteDriver "gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 引入版本化迁移前由 AutoMigrate 建立、金额列为 varchar(50) 的旧表
+
+type legacyOrder struct {
+	OutTradeNo  string `gorm:"column:out_trade_no;primaryKey;size:255"`
+	UserID      string `gorm:"column:user_id;size:255"`
+	TotalAmount string `gorm:"column:total_amount;type:varchar(50)"`
+	ShowAmount  string `gorm:"column:show_amount;type:varchar(50)"`
+	Discount    string `gorm:"column:discount;type:varchar(50);default:'0.00'"`
+	CreatedAt   int64  `gorm:"column:created_at"`
+}
+
+func (legacyOrder) TableName() string {
+	return "orders"
+}
+
+type legacySponsor struct {
+	UserID       string  `gorm:"column:user_id;primaryKey;size:255"`
+	Name         string  `gorm:"column:name;size:255"`
+	AllSumAmount *string `gorm:"column:all_sum_amount;type:varchar(50)"`
+	LastPayTime  *int64  `gorm:"column:last_pay_time"`
+}
+
+func (legacySponsor) TableName() string {
+	return "sponsors"
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqliteDriver.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取数据库实例失败: %v", err)
+	}
+	// 内存数据库随连接销毁，固定单个连接
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func columnType(t *testing.T, db *gorm.DB, table string, column string) string {
+	t.Helper()
+	columnTypes, err := db.Migrator().ColumnTypes(table)
+	if err != nil {
+		t.Fatalf("读取 %s 表结构失败: %v", table, err)
+	}
+	for _, columnType := range columnTypes {
+		if columnType.Name() == column {
+			return strings.ToLower(columnType.DatabaseTypeName())
+		}
+	}
+	t.Fatalf("%s 表缺少 %s 列", table, column)
+	return ""
+}
+
+func TestBaselineConvertsLegacyAmountColumns(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&legacyOrder{}, &legacySponsor{}); err != nil {
+		t.Fatalf("创建旧表失败: %v", err)
+	}
+
+	orders := []legacyOrder{
+		{OutTradeNo: "o1", UserID: "u1", TotalAmount: "5.00", ShowAmount: "5", Discount: "0.00", CreatedAt: 1},
+		{OutTradeNo: "o2", UserID: "u2", TotalAmount: " 100.5 ", ShowAmount: "", Discount: "-1.20", CreatedAt: 2},
+		{OutTradeNo: "o3", UserID: "u3", TotalAmount: "20", ShowAmount: "20.00", Discount: "", CreatedAt: 3},
+	}
+	if err := db.Create(&orders).Error; err != nil {
+		t.Fatalf("写入旧订单失败: %v", err)
+	}
+	lastPayTime := int64(1700000000)
+	sponsors := []legacySponsor{
+		{UserID: "u1", Name: "a", AllSumAmount: stringPtr("99.00"), LastPayTime: &lastPayTime},
+		{UserID: "u2", Name: "b", AllSumAmount: nil, LastPayTime: &lastPayTime},
+	}
+	if err := db.Create(&sponsors).Error; err != nil {
+		t.Fatalf("写入旧赞助者失败: %v", err)
+	}
+
+	if _, err := MigrateUp(context.Background(), db, 1, false); err != nil {
+		t.Fatalf("执行基线迁移失败: %v", err)
+	}
+
+	for _, column := range baselineAmountColumns {
+		if got := columnType(t, db, column.table, column.column); !strings.HasPrefix(got, "decimal") {
+			t.Errorf("%s.%s 类型为 %s，want decimal", column.table, column.column, got)
+		}
+	}
+
+	var converted []models.Order
+	if err := db.Order("out_trade_no asc").Find(&converted).Error; err != nil {
+		t.Fatalf("读取转换后的订单失败: %v", err)
+	}
+	wantOrders := []struct {
+		total, show, discount models.Money
+	}{
+		{500, 500, 0},
+		{10050, 0, -120},
+		{2000, 2000, 0},
+	}
+	if len(converted) != len(wantOrders) {
+		t.Fatalf("转换后订单数 = %d, want %d", len(converted), len(wantOrders))
+	}
+	for i, want := range wantOrders {
+		got := converted[i]
+		if got.TotalAmount != want.total || got.ShowAmount != want.show || got.Discount != want.discount {
+			t.Errorf("订单 %s = (%s, %s, %s), want (%s, %s, %s)", got.OutTradeNo,
+				got.TotalAmount, got.ShowAmount, got.Discount, want.total, want.show, want.discount)
+		}
+	}
+
+	// 转换前按字符串比较 "100.5" < "20"，转换后按数值排序
+	var ordered []string
+	if err := db.Model(&models.Order{}).Order("total_amount desc").Pluck("out_trade_no", &ordered).Error; err != nil {
+		t.Fatalf("按金额排序失败: %v", err)
+	}
+	if strings.Join(ordered, ",") != "o2,o3,o1" {
+		t.Errorf("按金额降序 = %v, want [o2 o3 o1]", ordered)
+	}
+
+	var sponsorAmounts []models.Sponsor
+	if err := db.Order("user_id asc").Find(&sponsorAmounts).Error; err != nil {
+		t.Fatalf("读取转换后的赞助者失败: %v", err)
+	}
+	if len(sponsorAmounts) != 2 || sponsorAmounts[0].AllSumAmount != 9900 || sponsorAmounts[1].AllSumAmount != 0 {
+		t.Errorf("转换后的累计金额 = %+v, want [99.00 0.00]", sponsorAmounts)
+	}
+
+	// 转换后的表仍可写入新记录
+	if err := db.Create(&models.Order{OutTradeNo: "o4", UserID: "u4", TotalAmount: 1}).Error; err != nil {
+		t.Errorf("转换后写入订单失败: %v", err)
+	}
+}
+
+func TestBaselineRejectsInvalidLegacyAmount(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&legacyOrder{}); err != nil {
+		t.Fatalf("创建旧表失败: %v", err)
+	}
+	orders := []legacyOrder{
+		{OutTradeNo: "o1", TotalAmount: " 5.00 "},
+		{OutTradeNo: "o2", TotalAmount: "¥5"},
+	}
+	if err := db.Create(&orders).Error; err != nil {
+		t.Fatalf("写入旧订单失败: %v", err)
+	}
+
+	applied, err := MigrateUp(context.Background(), db, 1, false)
+	if err == nil || !strings.Contains(err.Error(), "orders.total_amount") {
+		t.Fatalf("MigrateUp error = %v, want orders.total_amount 数据非法", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("已执行的迁移 = %d, want 0", len(applied))
+	}
+
+	// 迁移在事务中回滚，列类型与数据均保持原样
+	if got := columnType(t, db, "orders", "total_amount"); !strings.HasPrefix(got, "varchar") {
+		t.Errorf("orders.total_amount 类型为 %s，want varchar", got)
+	}
+	var amounts []string
+	if err := db.Model(&legacyOrder{}).Order("out_trade_no asc").Pluck("total_amount", &amounts).Error; err != nil {
+		t.Fatalf("读取订单失败: %v", err)
+	}
+	if strings.Join(amounts, "|") != " 5.00 |¥5" {
+		t.Errorf("订单金额 = %q, want 未修改", amounts)
+	}
+}
+
+func TestPreviewUpDoesNotModifyLegacyTables(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&legacyOrder{}); err != nil {
+		t.Fatalf("创建旧表失败: %v", err)
+	}
+	if err := db.Create(&legacyOrder{OutTradeNo: "o1", TotalAmount: " 5 "}).Error; err != nil {
+		t.Fatalf("写入旧订单失败: %v", err)
+	}
+
+	plan, err := MigrateUp(context.Background(), db, 0, true)
+	if err != nil {
+		t.Fatalf("MigrateUp dry-run error = %v", err)
+	}
+	statements, err := PreviewUp(context.Background(), db, plan[0])
+	if err != nil {
+		t.Fatalf("PreviewUp error = %v", err)
+	}
+	if len(statements) == 0 || !strings.Contains(strings.Join(statements, "\n"), "TRIM(`total_amount`)") {
+		t.Errorf("预览语句缺少金额规范化: %v", statements)
+	}
+
+	if db.Migrator().HasTable(&schemaMigration{}) {
+		t.Error("预览创建了 schema_migrations 表")
+	}
+	if got := columnType(t, db, "orders", "total_amount"); !strings.HasPrefix(got, "varchar") {
+		t.Errorf("orders.total_amount 类型为 %s，want varchar", got)
+	}
+	var amount string
+	if err := db.Model(&legacyOrder{}).Pluck("total_amount", &amount).Error; err != nil {
+		t.Fatalf("读取订单失败: %v", err)
+	}
+	if amount != " 5 " {
+		t.Errorf("订单金额 = %q, want 未修改", amount)
+	}
+}
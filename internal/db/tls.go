@@ -0,0 +1,93 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"afdianapi/internal/config"
+)
+
+// buildTLSConfig 按 DB_SSL_MODE 构建数据库连接的 TLS 配置，disable 时返回 nil。
+// skip-verify 不校验服务端证书；verify-ca 校验证书链但不校验主机名；
+// verify-full 同时校验主机名（默认取 DB_HOST，可由 DB_SSL_SERVER_NAME 覆盖）。
+// 未配置 DB_SSL_CA 时使用系统根证书。
+func buildTLSConfig(cfg config.DatabaseConfig) (*tls.Config, error) {
+	if cfg.SSLMode == config.DBSSLModeDisable {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.Host,
+	}
+	if cfg.SSLServerName != "" {
+		tlsConfig.ServerName = cfg.SSLServerName
+	}
+
+	if cfg.SSLCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+		if err != nil {
+			return nil, fmt.Errorf("加载数据库客户端证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	roots, err := loadRootCAs(cfg.SSLCA)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.SSLMode {
+	case config.DBSSLModeSkipVerify:
+		tlsConfig.InsecureSkipVerify = true
+	case config.DBSSLModeVerifyCA:
+		// 关闭内置校验（其中包含主机名校验），改为在握手后只校验证书链
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyCertificateChain(state, roots)
+		}
+	default:
+		tlsConfig.RootCAs = roots
+	}
+
+	return tlsConfig, nil
+}
+
+// loadRootCAs 读取 PEM 格式的 CA 证书，path 为空时返回 nil 以使用系统根证书
+func loadRootCAs(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取数据库 CA 证书失败: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("数据库 CA 证书 %s 中没有有效的 PEM 证书", path)
+	}
+	return pool, nil
+}
+
+func verifyCertificateChain(state tls.ConnectionState, roots *x509.CertPool) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("数据库服务端未提供证书")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if _, err := state.PeerCertificates[0].Verify(opts); err != nil {
+		return fmt.Errorf("数据库服务端证书校验失败: %w", err)
+	}
+	return nil
+}
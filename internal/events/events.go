@@ -0,0 +1,79 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"afdianapi/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SponsorPayload sponsor.new / sponsor.updated 事件的数据，只包含 /sponsor 公开的字段
+type SponsorPayload struct {
+	Name         string       `json:"name"`
+	Avatar       *string      `json:"avatar"`
+	AllSumAmount models.Money `json:"all_sum_amount"`
+	LastPayTime  *int64       `json:"last_pay_time"`
+}
+
+// OrderPayload order.created 事件的数据，不含订单号、用户 ID、留言与收货信息。
+// name、avatar 取自已同步的赞助者，赞助者尚未同步时为 null
+type OrderPayload struct {
+	Name        *string      `json:"name"`
+	Avatar      *string      `json:"avatar"`
+	PlanID      *string      `json:"plan_id"`
+	Month       int          `json:"month"`
+	TotalAmount models.Money `json:"total_amount"`
+	CreatedAt   int64        `json:"created_at"`
+}
+
+// NewSponsorEvent 构造赞助者事件，eventType 为 sponsor.new 或 sponsor.updated
+func NewSponsorEvent(eventType string, sponsor models.Sponsor) models.Event {
+	return newEvent(eventType, SponsorPayload{
+		Name:         sponsor.Name,
+		Avatar:       sponsor.Avatar,
+		AllSumAmount: sponsor.AllSumAmount,
+		LastPayTime:  sponsor.LastPayTime,
+	})
+}
+
+// Publish 将事件写入事件日志，由 Hub 轮询后推送给订阅者
+func Publish(ctx context.Context, db *gorm.DB, records ...models.Event) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return db.WithContext(ctx).Create(&records).Error
+}
+
+// PublishOrderCreated 为新订单写入 order.created 事件
+func PublishOrderCreated(ctx context.Context, db *gorm.DB, order models.Order) error {
+	payload := OrderPayload{
+		PlanID:      order.PlanID,
+		Month:       order.Month,
+		TotalAmount: order.TotalAmount,
+		CreatedAt:   order.CreatedAt,
+	}
+
+	var sponsors []models.Sponsor
+	if err := db.WithContext(ctx).Where("user_id = ?", order.UserID).Limit(1).Find(&sponsors).Error; err != nil {
+		return err
+	}
+	if len(sponsors) > 0 {
+		payload.Name = &sponsors[0].Name
+		payload.Avatar = sponsors[0].Avatar
+	}
+
+	return Publish(ctx, db, newEvent(models.EventOrderCreated, payload))
+}
+
+func newEvent(eventType string, payload interface{}) models.Event {
+	// 载荷均为固定结构体，序列化不会失败
+	data, _ := json.Marshal(payload)
+	return models.Event{
+		Type:      eventType,
+		Data:      string(data),
+		CreatedAt: time.Now().Unix(),
+	}
+}
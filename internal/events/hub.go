@@ -0,0 +1,288 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"afdianapi/internal/config"
+	"afdianapi/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// subscriberBuffer 每个订阅者的待发送队列长度，写满时断开该订阅者，由客户端携带 Last-Event-ID 重连补发
+	subscriberBuffer = 64
+	// pollBatchSize 单次轮询读取的最大事件数
+	pollBatchSize = 500
+	// pruneInterval 清理过期事件的间隔
+	pruneInterval = 10 * time.Minute
+)
+
+// errSeqTaken 分配序号时事件已被其他副本编号
+var errSeqTaken = errors.New("事件已被编号")
+
+// Subscription 一个订阅者。C 在订阅者被断开或 Hub 停止时关闭
+type Subscription struct {
+	C  <-chan models.Event
+	ch chan models.Event
+}
+
+// Hub 轮询事件日志并分发给订阅者。事件只经由数据库传递，
+// 因此多副本部署时任一副本写入的事件都会推送到所有副本的订阅者。
+// 事件提交后由轮询的副本按 ID 顺序分配连续的 seq，已编号的事件不会再出现更小的序号，
+// 订阅者按 seq 顺序接收且续传不会遗漏或重复
+type Hub struct {
+	db           *gorm.DB
+	pollInterval time.Duration
+	retention    time.Duration
+	replayLimit  int
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	lastSeq     uint64
+	stopped     bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewHub(cfg config.EventsConfig, db *gorm.DB) *Hub {
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	replayLimit := cfg.ReplayLimit
+	if replayLimit < 0 {
+		replayLimit = 0
+	}
+
+	return &Hub{
+		db:           db,
+		pollInterval: pollInterval,
+		retention:    cfg.Retention,
+		replayLimit:  replayLimit,
+		subscribers:  make(map[*Subscription]struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// Start 从当前最新的事件之后开始轮询，启动前已写入的事件只通过 Replay 补发
+func (h *Hub) Start() error {
+	var lastSeq uint64
+	if err := h.db.Model(&models.Event{}).Select("COALESCE(MAX(seq), 0)").Scan(&lastSeq).Error; err != nil {
+		return err
+	}
+	h.lastSeq = lastSeq
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	go h.run(ctx)
+	log.Printf("[事件] 事件推送已启动，轮询间隔: %s", h.pollInterval)
+	return nil
+}
+
+// Stop 停止轮询并关闭所有订阅
+func (h *Hub) Stop() {
+	if h.cancel == nil {
+		return
+	}
+	h.cancel()
+	<-h.done
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopped = true
+	for sub := range h.subscribers {
+		h.remove(sub)
+	}
+}
+
+func (h *Hub) Subscribe() *Subscription {
+	ch := make(chan models.Event, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopped {
+		close(ch)
+		return sub
+	}
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[sub]; ok {
+		h.remove(sub)
+	}
+}
+
+// Replay 返回序号大于 afterSeq 的事件，按序号升序。超过 replayLimit 时只返回最新的部分，
+// 保证补发的事件与之后推送的事件之间没有缺口
+func (h *Hub) Replay(ctx context.Context, afterSeq uint64) ([]models.Event, error) {
+	if h.replayLimit == 0 {
+		return nil, nil
+	}
+
+	var records []models.Event
+	if err := h.db.WithContext(ctx).
+		Where("seq > ?", afterSeq).
+		Order("seq desc").
+		Limit(h.replayLimit).
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records, nil
+}
+
+func (h *Hub) run(ctx context.Context) {
+	defer close(h.done)
+
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+	lastPrune := time.Time{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		h.poll(ctx)
+		if h.retention > 0 && time.Since(lastPrune) >= pruneInterval {
+			h.prune(ctx)
+			lastPrune = time.Now()
+		}
+	}
+}
+
+// poll 为新提交的事件编号，再读取上次之后的事件并分发
+func (h *Hub) poll(ctx context.Context) {
+	if err := h.assignSeq(ctx); err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[事件] 分配事件序号失败: %v", err)
+		}
+		return
+	}
+
+	for {
+		var records []models.Event
+		if err := h.db.WithContext(ctx).
+			Where("seq > ?", h.lastSeq).
+			Order("seq asc").
+			Limit(pollBatchSize).
+			Find(&records).Error; err != nil {
+			if ctx.Err() == nil {
+				log.Printf("[事件] 读取事件日志失败: %v", err)
+			}
+			return
+		}
+		if len(records) == 0 {
+			return
+		}
+
+		h.lastSeq = *records[len(records)-1].Seq
+		h.broadcast(records)
+		if len(records) < pollBatchSize {
+			return
+		}
+	}
+}
+
+// assignSeq 在一个事务中为尚未编号的事件按 ID 顺序分配紧接当前最大值的序号。
+// 多个副本同时编号时，序号唯一索引使后提交的一方失败回滚，由下次轮询重试，
+// 因此序号可见时更小的序号必然已经可见
+func (h *Hub) assignSeq(ctx context.Context) error {
+	var pending []models.Event
+	if err := h.db.WithContext(ctx).
+		Select("id").
+		Where("seq IS NULL").
+		Order("id asc").
+		Limit(pollBatchSize).
+		Find(&pending).Error; err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var maxSeq uint64
+		if err := tx.Model(&models.Event{}).Select("COALESCE(MAX(seq), 0)").Scan(&maxSeq).Error; err != nil {
+			return err
+		}
+		for i, record := range pending {
+			result := tx.Model(&models.Event{}).
+				Where("id = ? AND seq IS NULL", record.ID).
+				Update("seq", maxSeq+uint64(i)+1)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errSeqTaken
+			}
+		}
+		return nil
+	})
+	if err == nil || errors.Is(err, errSeqTaken) {
+		return nil
+	}
+
+	// 其他副本已为这些事件编号时，序号冲突不是错误
+	var remaining int64
+	if countErr := h.db.WithContext(ctx).Model(&models.Event{}).
+		Where("seq IS NULL AND id <= ?", pending[len(pending)-1].ID).
+		Count(&remaining).Error; countErr == nil && remaining == 0 {
+		return nil
+	}
+	return err
+}
+
+func (h *Hub) broadcast(records []models.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		for _, record := range records {
+			select {
+			case sub.ch <- record:
+				continue
+			default:
+			}
+			// 订阅者消费过慢，断开后由客户端重连补发
+			h.remove(sub)
+			break
+		}
+	}
+}
+
+// prune 删除超过保留时长的事件
+func (h *Hub) prune(ctx context.Context) {
+	cutoff := time.Now().Add(-h.retention).Unix()
+	result := h.db.WithContext(ctx).Where("created_at < ?", cutoff).Delete(&models.Event{})
+	if result.Error != nil {
+		if ctx.Err() == nil {
+			log.Printf("[事件] 清理过期事件失败: %v", result.Error)
+		}
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("[事件] 已清理 %d 条过期事件", result.RowsAffected)
+	}
+}
+
+// remove 需在持有 mu 时调用
+func (h *Hub) remove(sub *Subscription) {
+	delete(h.subscribers, sub)
+	close(sub.ch)
+}
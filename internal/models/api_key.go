@@ -0,0 +1,17 @@
+package models
+
+// APIKey 访问非公开接口的密钥，仅保存 SHA-256 摘要
+type APIKey struct {
+	ID         uint   `gorm:"column:id;primaryKey;autoIncrement"`
+	Name       string `gorm:"column:name;size:255"`
+	Prefix     string `gorm:"column:prefix;size:16"`
+	KeyHash    string `gorm:"column:key_hash;size:64;uniqueIndex:idx_api_keys_key_hash"`
+	Scopes     string `gorm:"column:scopes;size:255"`
+	CreatedAt  int64  `gorm:"column:created_at"`
+	LastUsedAt *int64 `gorm:"column:last_used_at"`
+	RevokedAt  *int64 `gorm:"column:revoked_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
@@ -0,0 +1,22 @@
+package models
+
+// 事件类型
+const (
+	EventSponsorNew     = "sponsor.new"
+	EventSponsorUpdated = "sponsor.updated"
+	EventOrderCreated   = "order.created"
+)
+
+// Event 事件日志中的一条记录。Seq 在事件提交后由 Hub 按可见顺序分配，作为 SSE 的事件 ID 用于断线续传；
+// 自增的 ID 在并发写入时提交顺序可能与大小顺序不一致，不能用于续传
+type Event struct {
+	ID        uint    `gorm:"column:id;primaryKey;autoIncrement"`
+	Type      string  `gorm:"column:type;size:50"`
+	Data      string  `gorm:"column:data;type:text"`
+	CreatedAt int64   `gorm:"column:created_at;index:idx_events_created_at"`
+	Seq       *uint64 `gorm:"column:seq;uniqueIndex:idx_events_seq"`
+}
+
+func (Event) TableName() string {
+	return "events"
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money 以“分”为单位的金额，数据库中存储为 DECIMAL(20,2)，
+// 字符串形式与爱发电一致，固定两位小数，如 "99.00"。
+// 模型中的 default 标签需写成整数（default:0）：gorm 写入零值时会把标签值按 int64 赋给字段，
+// "0.00" 会解析失败。库中的列默认值以迁移为准，与标签数值相同即可
+type Money int64
+
+// ErrInvalidMoney 金额格式无效
+var ErrInvalidMoney = errors.New("金额格式无效")
+
+// ParseMoney 精确解析十进制金额字符串，最多保留两位有效小数，不经过浮点数转换；
+// 空字符串视为 0
+func ParseMoney(raw string) (Money, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return 0, nil
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, raw)
+	}
+	// 多余的小数位只允许为 0，否则会丢失精度
+	if len(fracPart) > 2 {
+		if strings.Trim(fracPart[2:], "0") != "" || !isDigits(fracPart[2:]) {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, raw)
+		}
+		fracPart = fracPart[:2]
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, raw)
+	}
+	fracPart += strings.Repeat("0", 2-len(fracPart))
+
+	var yuan int64
+	if intPart != "" {
+		var err error
+		yuan, err = strconv.ParseInt(intPart, 10, 64)
+		if err != nil || yuan > (math.MaxInt64-99)/100 {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, raw)
+		}
+	}
+	cents, _ := strconv.ParseInt(fracPart, 10, 64)
+
+	value := yuan*100 + cents
+	if negative {
+		value = -value
+	}
+	return Money(value), nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String 返回两位小数的金额字符串
+func (m Money) String() string {
+	value := int64(m)
+	sign := ""
+	if value < 0 {
+		sign = "-"
+	}
+	abs := uint64(value)
+	if value < 0 {
+		abs = uint64(-value)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, abs/100, abs%100)
+}
+
+// Value 以十进制字符串写入数据库，避免浮点误差
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan 读取 DECIMAL 列，兼容驱动返回的字符串、整数与浮点数
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	case int64:
+		*m = Money(v * 100)
+		return nil
+	case float64:
+		*m = Money(math.Round(v * 100))
+		return nil
+	default:
+		return fmt.Errorf("%w: 不支持的类型 %T", ErrInvalidMoney, src)
+	}
+}
+
+func (m *Money) scanString(s string) error {
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// MarshalJSON 输出为字符串，如 "99.00"
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + m.String() + `"`), nil
+}
+
+// UnmarshalJSON 同时接受字符串与数字形式的金额
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Money
+		wantErr bool
+	}{
+		{name: "空字符串", raw: "", want: 0},
+		{name: "空白", raw: "   ", want: 0},
+		{name: "整数", raw: "99", want: 9900},
+		{name: "两位小数", raw: "99.00", want: 9900},
+		{name: "一位小数", raw: "5.5", want: 550},
+		{name: "首尾空白", raw: " 12.30 ", want: 1230},
+		{name: "省略整数部分", raw: ".5", want: 50},
+		{name: "省略小数部分", raw: "5.", want: 500},
+		{name: "正号", raw: "+3", want: 300},
+		{name: "负数", raw: "-1.5", want: -150},
+		{name: "负数小于一元", raw: "-0.05", want: -5},
+		{name: "多余的零小数位", raw: "1.2300", want: 123},
+		{name: "超过两位的有效小数", raw: "1.234", wantErr: true},
+		{name: "不做四舍五入", raw: "0.005", wantErr: true},
+		{name: "只有小数点", raw: ".", wantErr: true},
+		{name: "只有符号", raw: "-", wantErr: true},
+		{name: "非数字", raw: "abc", wantErr: true},
+		{name: "科学计数法", raw: "1e3", wantErr: true},
+		{name: "千分位", raw: "1,000.00", wantErr: true},
+		{name: "多个小数点", raw: "1.2.3", wantErr: true},
+		{name: "溢出", raw: "92233720368547758.08", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMoney) {
+					t.Fatalf("ParseMoney(%q) error = %v, want ErrInvalidMoney", tt.raw, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q) error = %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney(%q) = %d, want %d", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		value Money
+		want  string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{550, "5.50"},
+		{9900, "99.00"},
+		{123456789, "1234567.89"},
+		{-5, "-0.05"},
+		{-150, "-1.50"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.value.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", tt.value, got, tt.want)
+		}
+		parsed, err := ParseMoney(tt.want)
+		if err != nil || parsed != tt.value {
+			t.Errorf("ParseMoney(%q) = %d, %v, want %d", tt.want, parsed, err, tt.value)
+		}
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		want    Money
+		wantErr bool
+	}{
+		{name: "nil", src: nil, want: 0},
+		{name: "[]byte", src: []byte("99.00"), want: 9900},
+		{name: "[]byte 负数", src: []byte("-0.50"), want: -50},
+		{name: "string", src: "12.3", want: 1230},
+		{name: "string 空值", src: "", want: 0},
+		{name: "string 非法", src: "abc", wantErr: true},
+		{name: "int64", src: int64(7), want: 700},
+		{name: "int64 负数", src: int64(-2), want: -200},
+		{name: "float64", src: 19.99, want: 1999},
+		{name: "float64 浮点误差", src: 0.1 + 0.2, want: 30},
+		{name: "float64 负数", src: -0.29, want: -29},
+		{name: "不支持的类型", src: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money = 1
+			err := got.Scan(tt.src)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMoney) {
+					t.Fatalf("Scan(%#v) error = %v, want ErrInvalidMoney", tt.src, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan(%#v) error = %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("Scan(%#v) = %d, want %d", tt.src, got, tt.want)
+			}
+		})
+	}
+}
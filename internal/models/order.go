@@ -7,13 +7,13 @@ type Order struct {
 	UserPrivateID  *string    `gorm:"column:user_private_id;size:255"`
 	PlanID         *string    `gorm:"column:plan_id;size:255;index:idx_orders_plan_id"`
 	Month          int        `gorm:"column:month;default:1"`
-	TotalAmount    string     `gorm:"column:total_amount;size:50"`
-	ShowAmount     string     `gorm:"column:show_amount;size:50"`
+	TotalAmount    Money      `gorm:"column:total_amount;type:decimal(20,2)"`
+	ShowAmount     Money      `gorm:"column:show_amount;type:decimal(20,2)"`
 	Status         int        `gorm:"column:status;index:idx_orders_status"`
 	Remark         *string    `gorm:"column:remark;type:text"`
 	RedeemID       *string    `gorm:"column:redeem_id;size:255"`
 	ProductType    int        `gorm:"column:product_type;default:0"`
-	Discount       string     `gorm:"column:discount;size:50;default:'0.00'"`
+	Discount       Money      `gorm:"column:discount;type:decimal(20,2);default:0"`
 	AddressPerson  *string    `gorm:"column:address_person;size:255"`
 	AddressPhone   *string    `gorm:"column:address_phone;size:255"`
 	AddressAddress *string    `gorm:"column:address_address;type:text"`
@@ -0,0 +1,42 @@
+package models
+
+const (
+	SyncJobSponsors = "sponsors"
+	SyncJobOrders   = "orders"
+)
+
+const (
+	SyncTriggerCron    = "cron"
+	SyncTriggerManual  = "manual"
+	SyncTriggerStartup = "startup"
+)
+
+const (
+	SyncRunStatusRunning   = "running"
+	SyncRunStatusSuccess   = "success"
+	SyncRunStatusPartial   = "partial"
+	SyncRunStatusFailed    = "failed"
+	SyncRunStatusCancelled = "cancelled"
+)
+
+// SyncRun 一次同步任务的执行记录
+type SyncRun struct {
+	ID            uint    `gorm:"column:id;primaryKey;autoIncrement"`
+	Job           string  `gorm:"column:job;size:50;index:idx_sync_runs_job_started_at,priority:1"`
+	Trigger       string  `gorm:"column:triggered_by;size:20"`
+	Mode          string  `gorm:"column:mode;size:20"`
+	Status        string  `gorm:"column:status;size:20;index:idx_sync_runs_status"`
+	StartedAt     int64   `gorm:"column:started_at;index:idx_sync_runs_job_started_at,priority:2"`
+	FinishedAt    *int64  `gorm:"column:finished_at"`
+	PagesFetched  int     `gorm:"column:pages_fetched;default:0"`
+	RowsInserted  int     `gorm:"column:rows_inserted;default:0"`
+	RowsUpdated   int     `gorm:"column:rows_updated;default:0"`
+	RowsUnchanged int     `gorm:"column:rows_unchanged;default:0"`
+	RowsSkipped   int     `gorm:"column:rows_skipped;default:0"`
+	RowsRemoved   int     `gorm:"column:rows_removed;default:0"`
+	Error         *string `gorm:"column:error;type:text"`
+}
+
+func (SyncRun) TableName() string {
+	return "sync_runs"
+}
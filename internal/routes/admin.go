@@ -0,0 +1,258 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"afdianapi/internal/auth"
+	"afdianapi/internal/cron"
+	"afdianapi/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type syncRunResponse struct {
+	ID            uint    `json:"id"`
+	Job           string  `json:"job"`
+	Trigger       string  `json:"trigger"`
+	Mode          string  `json:"mode"`
+	Status        string  `json:"status"`
+	StartedAt     int64   `json:"started_at"`
+	FinishedAt    *int64  `json:"finished_at"`
+	PagesFetched  int     `json:"pages_fetched"`
+	RowsInserted  int     `json:"rows_inserted"`
+	RowsUpdated   int     `json:"rows_updated"`
+	RowsUnchanged int     `json:"rows_unchanged"`
+	RowsSkipped   int     `json:"rows_skipped"`
+	RowsRemoved   int     `json:"rows_removed"`
+	Error         *string `json:"error"`
+}
+
+type syncJobStatus struct {
+	Running     bool             `json:"running"`
+	Current     *syncRunResponse `json:"current"`
+	Last        *syncRunResponse `json:"last"`
+	LastSuccess *syncRunResponse `json:"last_success"`
+}
+
+type createAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+}
+
+func registerAdmin(router *gin.Engine, db *gorm.DB, scheduler *cron.Scheduler) {
+	admin := router.Group("/admin", requireScope(db, auth.ScopeAdmin))
+
+	admin.POST("/keys", func(c *gin.Context) {
+		var req createAPIKeyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"ec":   400,
+				"em":   "请求体格式错误",
+				"data": nil,
+			})
+			return
+		}
+
+		scopes, err := auth.ParseScopes(strings.Join(req.Scopes, ","))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"ec":   400,
+				"em":   err.Error(),
+				"data": nil,
+			})
+			return
+		}
+
+		plain, key, err := auth.CreateKey(c.Request.Context(), db, req.Name, scopes)
+		if err != nil {
+			respondInternalError(c)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"ec": 200,
+			"em": "",
+			"data": gin.H{
+				"key":     plain,
+				"api_key": toAPIKeyResponse(*key),
+			},
+		})
+	})
+
+	admin.GET("/keys", func(c *gin.Context) {
+		keys, err := auth.ListKeys(c.Request.Context(), db)
+		if err != nil {
+			respondInternalError(c)
+			return
+		}
+
+		list := make([]apiKeyResponse, 0, len(keys))
+		for _, key := range keys {
+			list = append(list, toAPIKeyResponse(key))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"ec":   200,
+			"em":   "",
+			"data": gin.H{"list": list},
+		})
+	})
+
+	admin.DELETE("/keys/:id", func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"ec":   400,
+				"em":   "密钥 ID 必须是正整数",
+				"data": nil,
+			})
+			return
+		}
+
+		if err := auth.RevokeKey(c.Request.Context(), db, uint(id)); err != nil {
+			if errors.Is(err, auth.ErrKeyNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{
+					"ec":   404,
+					"em":   "密钥不存在",
+					"data": nil,
+				})
+				return
+			}
+			respondInternalError(c)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"ec":   200,
+			"em":   "",
+			"data": nil,
+		})
+	})
+
+	admin.POST("/sync/sponsors", func(c *gin.Context) {
+		mode := cron.SyncMode(c.DefaultQuery("mode", string(cron.SyncModeFull)))
+		if mode != cron.SyncModeFull && mode != cron.SyncModeIncremental {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"ec":   400,
+				"em":   "mode 必须是 full 或 incremental",
+				"data": nil,
+			})
+			return
+		}
+
+		respondSyncTriggered(c, scheduler.TriggerSponsors(mode), gin.H{
+			"job":  models.SyncJobSponsors,
+			"mode": mode,
+		})
+	})
+
+	admin.POST("/sync/orders", func(c *gin.Context) {
+		respondSyncTriggered(c, scheduler.TriggerOrders(), gin.H{
+			"job": models.SyncJobOrders,
+		})
+	})
+
+	admin.GET("/sync/status", func(c *gin.Context) {
+		syncService := scheduler.SyncService()
+		data := gin.H{}
+		for _, job := range []string{models.SyncJobSponsors, models.SyncJobOrders} {
+			status := syncJobStatus{}
+			if current, ok := syncService.Progress(job); ok {
+				status.Running = true
+				status.Current = toSyncRunResponse(current)
+			}
+
+			last, err := findLatestSyncRun(db, job, "")
+			if err != nil {
+				respondInternalError(c)
+				return
+			}
+			status.Last = last
+
+			lastSuccess, err := findLatestSyncRun(db, job, models.SyncRunStatusSuccess)
+			if err != nil {
+				respondInternalError(c)
+				return
+			}
+			status.LastSuccess = lastSuccess
+
+			data[job] = status
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"ec":   200,
+			"em":   "",
+			"data": data,
+		})
+	})
+}
+
+func respondSyncTriggered(c *gin.Context, err error, data gin.H) {
+	if errors.Is(err, cron.ErrSyncInProgress) {
+		c.JSON(http.StatusConflict, gin.H{
+			"ec":   409,
+			"em":   "同步正在进行中",
+			"data": nil,
+		})
+		return
+	}
+	if errors.Is(err, cron.ErrSchedulerStopped) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"ec":   503,
+			"em":   "服务正在关闭",
+			"data": nil,
+		})
+		return
+	}
+	if err != nil {
+		respondInternalError(c)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"ec":   200,
+		"em":   "",
+		"data": data,
+	})
+}
+
+// findLatestSyncRun 查询任务最近一次已结束的运行，status 为空时不限状态
+func findLatestSyncRun(db *gorm.DB, job string, status string) (*syncRunResponse, error) {
+	query := db.Model(&models.SyncRun{}).Where("job = ?", job)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status <> ?", models.SyncRunStatusRunning)
+	}
+
+	var runs []models.SyncRun
+	if err := query.Order("started_at desc").Order("id desc").Limit(1).Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, nil
+	}
+	return toSyncRunResponse(runs[0]), nil
+}
+
+func toSyncRunResponse(run models.SyncRun) *syncRunResponse {
+	return &syncRunResponse{
+		ID:            run.ID,
+		Job:           run.Job,
+		Trigger:       run.Trigger,
+		Mode:          run.Mode,
+		Status:        run.Status,
+		StartedAt:     run.StartedAt,
+		FinishedAt:    run.FinishedAt,
+		PagesFetched:  run.PagesFetched,
+		RowsInserted:  run.RowsInserted,
+		RowsUpdated:   run.RowsUpdated,
+		RowsUnchanged: run.RowsUnchanged,
+		RowsSkipped:   run.RowsSkipped,
+		RowsRemoved:   run.RowsRemoved,
+		Error:         run.Error,
+	}
+}
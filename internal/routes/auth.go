@@ -0,0 +1,88 @@
+package routes
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"afdianapi/internal/auth"
+	"afdianapi/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// apiKeyContextKey 认证通过的密钥在 gin.Context 中的键
+const apiKeyContextKey = "api_key"
+
+// requireScope 校验 Authorization: Bearer <key> 或 X-API-Key 中的密钥是否拥有指定权限
+func requireScope(db *gorm.DB, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plain := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if plain == "" {
+			plain = c.GetHeader("X-API-Key")
+		}
+		if plain == "" {
+			abortUnauthorized(c)
+			return
+		}
+
+		key, err := auth.Authenticate(c.Request.Context(), db, plain)
+		if err != nil {
+			if !errors.Is(err, auth.ErrInvalidKey) {
+				log.Printf("[认证] 校验密钥失败: %v", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"ec":   500,
+					"em":   "服务器内部错误",
+					"data": nil,
+				})
+				return
+			}
+			abortUnauthorized(c)
+			return
+		}
+
+		if !auth.HasScope(key, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"ec":   403,
+				"em":   "权限不足，需要 " + scope,
+				"data": nil,
+			})
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+func abortUnauthorized(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"ec":   401,
+		"em":   "未授权",
+		"data": nil,
+	})
+}
+
+type apiKeyResponse struct {
+	ID         uint     `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  int64    `json:"created_at"`
+	LastUsedAt *int64   `json:"last_used_at"`
+	RevokedAt  *int64   `json:"revoked_at"`
+}
+
+func toAPIKeyResponse(key models.APIKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     strings.Split(key.Scopes, ","),
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondCacheable 返回可被浏览器与 CDN 缓存的 JSON 响应：ETag 为响应体摘要（强校验），
+// Last-Modified 为数据的最后同步时间（为零值时不发送）。
+// If-None-Match 优先于 If-Modified-Since，命中时返回不带响应体的 304
+func respondCacheable(c *gin.Context, body []byte, lastModified time.Time, maxAge time.Duration, staleWhileRevalidate time.Duration) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	header := c.Writer.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", buildCacheControl(maxAge, staleWhileRevalidate))
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c.Request, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Data(http.StatusOK, jsonContentType, body)
+}
+
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+	ifModifiedSince := req.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	// HTTP 日期精确到秒
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// etagMatches 按 If-None-Match 的弱比较规则判断是否匹配
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func buildCacheControl(maxAge time.Duration, staleWhileRevalidate time.Duration) string {
+	if maxAge <= 0 {
+		return "no-cache"
+	}
+	value := "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+	if staleWhileRevalidate > 0 {
+		value += ", stale-while-revalidate=" + strconv.Itoa(int(staleWhileRevalidate.Seconds()))
+	}
+	return value
+}
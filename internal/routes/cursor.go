@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// pageCursor 游标分页的位置：上一页最后一条记录的排序值与主键，对客户端不透明
+type pageCursor struct {
+	Value int64  `json:"v"`
+	ID    string `json:"id"`
+}
+
+func encodeCursor(value int64, id string) string {
+	raw, _ := json.Marshal(pageCursor{Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(raw string) (*pageCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID == "" {
+		return nil, errors.New("游标缺少主键")
+	}
+	return &cursor, nil
+}
+
+// parseCursor 解析 cursor 参数。携带 cursor 参数（首页可为空值）即启用游标分页，
+// 此时不能同时使用 page。参数非法时已写入 400 响应
+func parseCursor(c *gin.Context) (cursor *pageCursor, enabled bool, ok bool) {
+	raw, enabled := c.GetQuery("cursor")
+	if !enabled {
+		return nil, false, true
+	}
+	if c.Query("page") != "" {
+		respondBadRequest(c, "cursor 与 page 不能同时使用")
+		return nil, true, false
+	}
+	if raw == "" {
+		return nil, true, true
+	}
+
+	cursor, err := decodeCursor(raw)
+	if err != nil {
+		respondBadRequest(c, "cursor 无效")
+		return nil, true, false
+	}
+	return cursor, true, true
+}
+
+// applyCursor 追加“位于游标之后”的条件，排序为 (column, idColumn) 同向，
+// desc 时取更小的值，asc 时取更大的值。column 须为 NOT NULL 列，NULL 不参与比较会被跳过；
+// 列名只能来自代码中的常量
+func applyCursor(tx *gorm.DB, cursor *pageCursor, column string, idColumn string, order string) *gorm.DB {
+	if cursor == nil {
+		return tx
+	}
+	op := "<"
+	if order == "asc" {
+		op = ">"
+	}
+	return tx.Where(
+		"("+column+" "+op+" ? OR ("+column+" = ? AND "+idColumn+" "+op+" ?))",
+		cursor.Value, cursor.Value, cursor.ID,
+	)
+}
@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		value int64
+		id    string
+	}{
+		{0, "a"},
+		{1700000000, "u_1234567890abcdef"},
+		{-1, "负数"},
+		{9223372036854775807, "max"},
+		{42, "含/+=的主键"},
+	}
+
+	for _, tt := range tests {
+		raw := encodeCursor(tt.value, tt.id)
+		cursor, err := decodeCursor(raw)
+		if err != nil {
+			t.Fatalf("decodeCursor(encodeCursor(%d, %q)) error = %v", tt.value, tt.id, err)
+		}
+		if cursor.Value != tt.value || cursor.ID != tt.id {
+			t.Errorf("round trip (%d, %q) = (%d, %q)", tt.value, tt.id, cursor.Value, cursor.ID)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{name: "非 base64", raw: "!!!"},
+		{name: "非 JSON", raw: base64.RawURLEncoding.EncodeToString([]byte("not json"))},
+		{name: "缺少主键", raw: base64.RawURLEncoding.EncodeToString([]byte(`{"v":1}`))},
+		{name: "标准 base64 填充", raw: base64.URLEncoding.EncodeToString([]byte(`{"v":1,"id":"a"}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cursor, err := decodeCursor(tt.raw); err == nil {
+				t.Errorf("decodeCursor(%q) = %+v, want error", tt.raw, cursor)
+			}
+		})
+	}
+}
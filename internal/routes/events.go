@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"afdianapi/internal/events"
+	"afdianapi/internal/models"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// eventsHeartbeatInterval 无事件时发送注释行的间隔，避免代理因空闲断开连接
+const eventsHeartbeatInterval = 15 * time.Second
+
+// registerEvents 以 Server-Sent Events 推送赞助者与订单事件。与 /sponsor 一样公开访问，
+// 事件数据只包含公开字段。携带 Last-Event-ID（或 last_event_id 参数）时先补发其后的事件
+func registerEvents(router *gin.Engine, hub *events.Hub) {
+	router.GET("/events", func(c *gin.Context) {
+		lastSeq, resume, ok := parseLastEventID(c)
+		if !ok {
+			return
+		}
+
+		// 先订阅再补发，补发期间产生的事件不会丢失，重复的由 lastSeq 过滤
+		sub := hub.Subscribe()
+		defer hub.Unsubscribe(sub)
+
+		var replay []models.Event
+		if resume {
+			var err error
+			replay, err = hub.Replay(c.Request.Context(), lastSeq)
+			if err != nil {
+				respondInternalError(c)
+				return
+			}
+		}
+
+		header := c.Writer.Header()
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+		// 关闭 Nginx 的响应缓冲
+		header.Set("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		c.Writer.WriteHeaderNow()
+
+		for _, record := range replay {
+			writeEvent(c, record)
+			lastSeq = *record.Seq
+		}
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(eventsHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case record, open := <-sub.C:
+				if !open {
+					return
+				}
+				if *record.Seq <= lastSeq {
+					continue
+				}
+				writeEvent(c, record)
+				lastSeq = *record.Seq
+				c.Writer.Flush()
+			case <-heartbeat.C:
+				if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+					return
+				}
+				c.Writer.Flush()
+			}
+		}
+	})
+}
+
+func writeEvent(c *gin.Context, record models.Event) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(*record.Seq, 10),
+		Event: record.Type,
+		Data:  record.Data,
+	})
+}
+
+// parseLastEventID 读取续传位置，浏览器重连时自动携带 Last-Event-ID 请求头；
+// 首次连接无法设置请求头时可使用 last_event_id 参数。参数非法时已写入 400 响应
+func parseLastEventID(c *gin.Context) (lastSeq uint64, resume bool, ok bool) {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw == "" {
+		return 0, false, true
+	}
+
+	value, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		respondBadRequest(c, "Last-Event-ID 无效")
+		return 0, false, false
+	}
+	return value, true, true
+}
@@ -0,0 +1,229 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"afdianapi/internal/auth"
+	"afdianapi/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type orderSkuResponse struct {
+	SkuID   string  `json:"sku_id"`
+	Count   int     `json:"count"`
+	Name    *string `json:"name"`
+	AlbumID *string `json:"album_id"`
+	Pic     *string `json:"pic"`
+}
+
+type orderResponse struct {
+	OutTradeNo     string             `json:"out_trade_no"`
+	CustomOrderID  *string            `json:"custom_order_id"`
+	UserID         string             `json:"user_id"`
+	UserPrivateID  *string            `json:"user_private_id"`
+	PlanID         *string            `json:"plan_id"`
+	Month          int                `json:"month"`
+	TotalAmount    models.Money       `json:"total_amount"`
+	ShowAmount     models.Money       `json:"show_amount"`
+	Status         int                `json:"status"`
+	Remark         *string            `json:"remark"`
+	RedeemID       *string            `json:"redeem_id"`
+	ProductType    int                `json:"product_type"`
+	Discount       models.Money       `json:"discount"`
+	AddressPerson  *string            `json:"address_person"`
+	AddressPhone   *string            `json:"address_phone"`
+	AddressAddress *string            `json:"address_address"`
+	CreatedAt      int64              `json:"created_at"`
+	UpdatedAt      int64              `json:"updated_at"`
+	Skus           []orderSkuResponse `json:"skus,omitempty"`
+}
+
+func registerOrders(router *gin.Engine, db *gorm.DB) {
+	orders := router.Group("/orders", requireScope(db, auth.ScopeReadOrders))
+
+	orders.GET("", func(c *gin.Context) {
+		page, perPage, ok := parsePagination(c)
+		if !ok {
+			return
+		}
+
+		cursor, cursorMode, ok := parseCursor(c)
+		if !ok {
+			return
+		}
+
+		query, ok := buildOrderQuery(c, db)
+		if !ok {
+			return
+		}
+
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			respondInternalError(c)
+			return
+		}
+
+		ordered := query.
+			Order("created_at desc").
+			Order("out_trade_no desc")
+
+		var records []models.Order
+		var err error
+		if cursorMode {
+			// 多取一条用于判断是否还有下一页
+			err = applyCursor(ordered, cursor, "created_at", "out_trade_no", "desc").
+				Limit(perPage + 1).
+				Find(&records).Error
+		} else {
+			err = ordered.
+				Limit(perPage).
+				Offset((page - 1) * perPage).
+				Find(&records).Error
+		}
+		if err != nil {
+			respondInternalError(c)
+			return
+		}
+
+		var nextCursor *string
+		if cursorMode && len(records) > perPage {
+			records = records[:perPage]
+			last := records[len(records)-1]
+			encoded := encodeCursor(last.CreatedAt, last.OutTradeNo)
+			nextCursor = &encoded
+		}
+
+		list := make([]orderResponse, 0, len(records))
+		for _, record := range records {
+			list = append(list, toOrderResponse(record))
+		}
+
+		data := gin.H{
+			"total_count": total,
+			"list":        list,
+		}
+		if cursorMode {
+			data["next_cursor"] = nextCursor
+		} else {
+			data["total_page"] = calcTotalPage(total, int64(perPage))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"ec":   200,
+			"em":   "",
+			"data": data,
+		})
+	})
+
+	orders.GET("/:out_trade_no", func(c *gin.Context) {
+		var records []models.Order
+		if err := db.WithContext(c.Request.Context()).
+			Preload("Skus", func(tx *gorm.DB) *gorm.DB {
+				return tx.Order("id asc")
+			}).
+			Where("out_trade_no = ?", c.Param("out_trade_no")).
+			Limit(1).
+			Find(&records).Error; err != nil {
+			respondInternalError(c)
+			return
+		}
+
+		if len(records) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{
+				"ec":   404,
+				"em":   "订单不存在",
+				"data": nil,
+			})
+			return
+		}
+
+		response := toOrderResponse(records[0])
+		if response.Skus == nil {
+			response.Skus = []orderSkuResponse{}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"ec":   200,
+			"em":   "",
+			"data": response,
+		})
+	})
+}
+
+// buildOrderQuery 根据查询参数构建订单筛选条件，参数非法时已写入 400 响应
+func buildOrderQuery(c *gin.Context, db *gorm.DB) (*gorm.DB, bool) {
+	query := db.WithContext(c.Request.Context()).Model(&models.Order{})
+
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if planID := c.Query("plan_id"); planID != "" {
+		query = query.Where("plan_id = ?", planID)
+	}
+
+	intFilters := []struct {
+		param  string
+		clause string
+	}{
+		{"status", "status = ?"},
+		{"product_type", "product_type = ?"},
+		{"created_from", "created_at >= ?"},
+		{"created_to", "created_at <= ?"},
+	}
+	for _, filter := range intFilters {
+		raw := c.Query(filter.param)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"ec":   400,
+				"em":   filter.param + " 必须是整数",
+				"data": nil,
+			})
+			return nil, false
+		}
+		query = query.Where(filter.clause, value)
+	}
+
+	// 新会话使 Count 与 Find 各自基于同一组条件克隆语句，互不干扰
+	return query.Session(&gorm.Session{}), true
+}
+
+func toOrderResponse(order models.Order) orderResponse {
+	var skus []orderSkuResponse
+	for _, sku := range order.Skus {
+		skus = append(skus, orderSkuResponse{
+			SkuID:   sku.SkuID,
+			Count:   sku.Count,
+			Name:    sku.Name,
+			AlbumID: sku.AlbumID,
+			Pic:     sku.Pic,
+		})
+	}
+
+	return orderResponse{
+		OutTradeNo:     order.OutTradeNo,
+		CustomOrderID:  order.CustomOrderID,
+		UserID:         order.UserID,
+		UserPrivateID:  order.UserPrivateID,
+		PlanID:         order.PlanID,
+		Month:          order.Month,
+		TotalAmount:    order.TotalAmount,
+		ShowAmount:     order.ShowAmount,
+		Status:         order.Status,
+		Remark:         order.Remark,
+		RedeemID:       order.RedeemID,
+		ProductType:    order.ProductType,
+		Discount:       order.Discount,
+		AddressPerson:  order.AddressPerson,
+		AddressPhone:   order.AddressPhone,
+		AddressAddress: order.AddressAddress,
+		CreatedAt:      order.CreatedAt,
+		UpdatedAt:      order.UpdatedAt,
+		Skus:           skus,
+	}
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 各路由共用的错误响应，格式与 /sponsor 一致：{"ec":..,"em":..,"data":null}
+
+func respondInternalError(c *gin.Context) {
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"ec":   500,
+		"em":   "服务器内部错误",
+		"data": nil,
+	})
+}
+
+func respondBadRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"ec":   400,
+		"em":   message,
+		"data": nil,
+	})
+}
@@ -1,67 +1,40 @@
 package routes
 
 import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
 	"net/http"
+	"net/url"
 	"strconv"
-	"sync"
 	"time"
 
+	"afdianapi/internal/cache"
+	"afdianapi/internal/config"
+	"afdianapi/internal/cron"
+	"afdianapi/internal/events"
 	"afdianapi/internal/models"
+	"afdianapi/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-type sponsorResponse struct {
-	Name         string  `json:"name"`
-	Avatar       *string `json:"avatar"`
-	AllSumAmount string  `json:"all_sum_amount"`
-	LastPayTime  *int64  `json:"last_pay_time"`
-}
-
-type sponsorCacheEntry struct {
-	payload   gin.H
-	expiresAt time.Time
-}
-
-type sponsorCache struct {
-	mu      sync.Mutex
-	entries map[string]sponsorCacheEntry
-}
-
-func newSponsorCache() *sponsorCache {
-	return &sponsorCache{
-		entries: make(map[string]sponsorCacheEntry),
-	}
-}
-
-func (c *sponsorCache) get(key string) (gin.H, bool) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+const jsonContentType = "application/json; charset=utf-8"
 
-	entry, ok := c.entries[key]
-	if !ok {
-		return nil, false
-	}
-	if time.Now().After(entry.expiresAt) {
-		delete(c.entries, key)
-		return nil, false
-	}
-	return entry.payload, true
-}
-
-func (c *sponsorCache) set(key string, payload gin.H, ttl time.Duration) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.entries[key] = sponsorCacheEntry{
-		payload:   payload,
-		expiresAt: time.Now().Add(ttl),
-	}
+type sponsorResponse struct {
+	Name         string       `json:"name"`
+	Avatar       *string      `json:"avatar"`
+	AllSumAmount models.Money `json:"all_sum_amount"`
+	LastPayTime  *int64       `json:"last_pay_time"`
 }
 
-func Register(router *gin.Engine, db *gorm.DB) {
-	cache := newSponsorCache()
+func Register(router *gin.Engine, cfg *config.Config, db *gorm.DB, client *services.AfdianClient, scheduler *cron.Scheduler, responseCache cache.Cache, eventHub *events.Hub) {
+	registerWebhook(router, db, client)
+	registerAdmin(router, db, scheduler)
+	registerOrders(router, db)
+	registerSponsorDetail(router, db)
+	registerEvents(router, eventHub)
 
 	router.GET("/health", func(c *gin.Context) {
 		sqlDB, err := db.DB()
@@ -87,24 +60,17 @@ func Register(router *gin.Engine, db *gorm.DB) {
 		})
 	})
 
+	sponsorLoader := cache.NewLoader(responseCache, cfg.Cache.TTL, cfg.Cache.StaleTTL)
 	router.GET("/sponsor", func(c *gin.Context) {
-		page, perPage, ok := parsePagination(c)
+		query, ok := parseSponsorListQuery(c)
 		if !ok {
 			return
 		}
 
-		cacheKey := buildSponsorCacheKey(page, perPage)
-		if cached, ok := cache.get(cacheKey); ok {
-			c.JSON(http.StatusOK, cached)
-			return
-		}
-
-		var sponsors []models.Sponsor
-		if err := db.Model(&models.Sponsor{}).
-			Order("last_pay_time desc").
-			Limit(perPage).
-			Offset((page - 1) * perPage).
-			Find(&sponsors).Error; err != nil {
+		entry, err := sponsorLoader.Get(c.Request.Context(), buildSponsorCacheKey(query), func(ctx context.Context) ([]byte, error) {
+			return loadSponsorEntry(ctx, db, query)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"ec":   500,
 				"em":   "服务器内部错误",
@@ -113,40 +79,131 @@ func Register(router *gin.Engine, db *gorm.DB) {
 			return
 		}
 
-		var total int64
-		if err := db.Model(&models.Sponsor{}).Count(&total).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"ec":   500,
-				"em":   "服务器内部错误",
-				"data": nil,
-			})
-			return
-		}
+		lastModified, body := decodeSponsorEntry(entry)
+		respondCacheable(c, body, lastModified, cfg.Cache.TTL, cfg.Cache.StaleTTL)
+	})
+}
 
-		list := make([]sponsorResponse, 0, len(sponsors))
-		for _, sponsor := range sponsors {
-			list = append(list, sponsorResponse{
-				Name:         sponsor.Name,
-				Avatar:       sponsor.Avatar,
-				AllSumAmount: sponsor.AllSumAmount,
-				LastPayTime:  sponsor.LastPayTime,
-			})
-		}
+// loadSponsorEntry 查询最后同步时间与赞助者列表，合并为一个缓存条目：
+// 前 8 字节为最后同步时间（Unix 秒，大端序），其后为响应体，保证 Last-Modified 与响应体来自同一次加载。
+// 先读同步时间再读列表，两次查询之间有同步写入时 Last-Modified 只会早于响应体，客户端不会因此错过更新
+func loadSponsorEntry(ctx context.Context, db *gorm.DB, query sponsorListQuery) ([]byte, error) {
+	lastSync, err := loadLastSyncTime(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	body, err := loadSponsorList(ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := make([]byte, 8+len(body))
+	binary.BigEndian.PutUint64(entry, uint64(lastSync))
+	copy(entry[8:], body)
+	return entry, nil
+}
 
-		payload := gin.H{
-			"ec": 200,
-			"em": "",
-			"data": gin.H{
-				"total_count": total,
-				"total_page":  calcTotalPage(total, int64(perPage)),
-				"list":        list,
-			},
+// decodeSponsorEntry 拆分缓存条目，尚未同步过时最后同步时间为零值
+func decodeSponsorEntry(entry []byte) (time.Time, []byte) {
+	if len(entry) < 8 {
+		return time.Time{}, entry
+	}
+	seconds := int64(binary.BigEndian.Uint64(entry))
+	if seconds <= 0 {
+		return time.Time{}, entry[8:]
+	}
+	return time.Unix(seconds, 0), entry[8:]
+}
+
+// loadLastSyncTime 读取赞助者数据最后一次发生变化的同步时间（Unix 秒），尚未同步过或无法解析时返回 0
+func loadLastSyncTime(ctx context.Context, db *gorm.DB) (int64, error) {
+	var records []models.SyncMetadata
+	if err := db.WithContext(ctx).
+		Where(&models.SyncMetadata{Key: "last_sync_time"}).
+		Limit(1).
+		Find(&records).Error; err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+	seconds, err := strconv.ParseInt(records[0].Value, 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+	return seconds, nil
+}
+
+// loadSponsorList 查询赞助者列表并序列化为完整的响应体，供缓存直接返回
+func loadSponsorList(ctx context.Context, db *gorm.DB, query sponsorListQuery) ([]byte, error) {
+	filtered := query.apply(db.WithContext(ctx).Model(&models.Sponsor{}))
+
+	var total int64
+	if err := filtered.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	ordered := filtered.
+		Order(query.orderBy()).
+		Order("user_id " + query.order)
+
+	var sponsors []models.Sponsor
+	if query.cursorMode {
+		// 多取一条用于判断是否还有下一页
+		if err := applyCursor(ordered, query.cursor, "last_pay_time", "user_id", query.order).
+			Limit(query.perPage + 1).
+			Find(&sponsors).Error; err != nil {
+			return nil, err
 		}
-		cache.set(cacheKey, payload, 5*time.Second)
-		c.JSON(http.StatusOK, payload)
+	} else if err := ordered.
+		Limit(query.perPage).
+		Offset((query.page - 1) * query.perPage).
+		Find(&sponsors).Error; err != nil {
+		return nil, err
+	}
+
+	var nextCursor *string
+	if query.cursorMode && len(sponsors) > query.perPage {
+		sponsors = sponsors[:query.perPage]
+		last := sponsors[len(sponsors)-1]
+		cursor := encodeCursor(derefInt64(last.LastPayTime), last.UserID)
+		nextCursor = &cursor
+	}
+
+	list := make([]sponsorResponse, 0, len(sponsors))
+	for _, sponsor := range sponsors {
+		list = append(list, sponsorResponse{
+			Name:         sponsor.Name,
+			Avatar:       sponsor.Avatar,
+			AllSumAmount: sponsor.AllSumAmount,
+			LastPayTime:  sponsor.LastPayTime,
+		})
+	}
+
+	data := gin.H{
+		"total_count": total,
+		"list":        list,
+	}
+	if query.cursorMode {
+		data["next_cursor"] = nextCursor
+	} else {
+		data["total_page"] = calcTotalPage(total, int64(query.perPage))
+	}
+
+	return json.Marshal(gin.H{
+		"ec":   200,
+		"em":   "",
+		"data": data,
 	})
 }
 
+func derefInt64(value *int64) int64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
 func parsePagination(c *gin.Context) (int, int, bool) {
 	page := 1
 	perPage := 20
@@ -190,6 +247,38 @@ func calcTotalPage(total int64, perPage int64) int64 {
 	return total/perPage + 1
 }
 
-func buildSponsorCacheKey(page int, perPage int) string {
-	return "sponsor:page=" + strconv.Itoa(page) + ":per_page=" + strconv.Itoa(perPage)
+func buildSponsorCacheKey(query sponsorListQuery) string {
+	return "sponsor:page=" + strconv.Itoa(query.page) +
+		":per_page=" + strconv.Itoa(query.perPage) +
+		":sort=" + query.sort +
+		":order=" + query.order +
+		":min_amount=" + formatOptionalMoney(query.minAmount) +
+		":since=" + formatOptionalInt64(query.since) +
+		":until=" + formatOptionalInt64(query.until) +
+		":q=" + url.QueryEscape(query.name) +
+		formatCursorKey(query)
+}
+
+func formatCursorKey(query sponsorListQuery) string {
+	if !query.cursorMode {
+		return ""
+	}
+	if query.cursor == nil {
+		return ":cursor="
+	}
+	return ":cursor=" + encodeCursor(query.cursor.Value, query.cursor.ID)
+}
+
+func formatOptionalInt64(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+func formatOptionalMoney(value *models.Money) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
 }
@@ -0,0 +1,254 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"afdianapi/internal/auth"
+	"afdianapi/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// orderStatusPaid 爱发电订单状态：已支付
+const orderStatusPaid = 2
+
+// sponsorSortColumns /sponsor 允许的排序字段及对应的排序表达式
+var sponsorSortColumns = map[string]string{
+	"last_pay_time":  "last_pay_time",
+	"first_pay_time": "first_pay_time",
+	"all_sum_amount": "all_sum_amount",
+	"name":           "name",
+}
+
+// sponsorNameSearchMaxLen 昵称搜索关键字的最大长度（字符数）
+const sponsorNameSearchMaxLen = 50
+
+// sponsorListQuery /sponsor 的分页、排序与筛选参数，字段均已校验并规范化
+type sponsorListQuery struct {
+	page      int
+	perPage   int
+	sort      string
+	order     string
+	minAmount *models.Money
+	since     *int64
+	until     *int64
+	name      string
+	// cursorMode 为 true 时使用游标分页，cursor 为空表示第一页
+	cursorMode bool
+	cursor     *pageCursor
+}
+
+type sponsorDetailResponse struct {
+	UserID       string       `json:"user_id"`
+	Name         string       `json:"name"`
+	Avatar       *string      `json:"avatar"`
+	AllSumAmount models.Money `json:"all_sum_amount"`
+	CreateTime   int64        `json:"create_time"`
+	FirstPayTime *int64       `json:"first_pay_time"`
+	LastPayTime  *int64       `json:"last_pay_time"`
+	UpdatedAt    int64        `json:"updated_at"`
+	DeletedAt    *int64       `json:"deleted_at"`
+}
+
+type sponsorStatsResponse struct {
+	OrderCount      int     `json:"order_count"`
+	FirstPlanID     *string `json:"first_plan_id"`
+	LastPlanID      *string `json:"last_plan_id"`
+	MonthsSponsored int     `json:"months_sponsored"`
+}
+
+func registerSponsorDetail(router *gin.Engine, db *gorm.DB) {
+	router.GET("/sponsor/:user_id", requireScope(db, auth.ScopeReadSponsors), func(c *gin.Context) {
+		userID := c.Param("user_id")
+		ctx := c.Request.Context()
+
+		// 已被清理的赞助者同样可以查看，通过 deleted_at 区分
+		var sponsors []models.Sponsor
+		if err := db.WithContext(ctx).Unscoped().
+			Where("user_id = ?", userID).
+			Limit(1).
+			Find(&sponsors).Error; err != nil {
+			respondInternalError(c)
+			return
+		}
+		if len(sponsors) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{
+				"ec":   404,
+				"em":   "赞助者不存在",
+				"data": nil,
+			})
+			return
+		}
+
+		var orders []models.Order
+		if err := db.WithContext(ctx).
+			Where("user_id = ?", userID).
+			Order("created_at desc").
+			Order("out_trade_no desc").
+			Find(&orders).Error; err != nil {
+			respondInternalError(c)
+			return
+		}
+
+		list := make([]orderResponse, 0, len(orders))
+		for _, order := range orders {
+			list = append(list, toOrderResponse(order))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"ec": 200,
+			"em": "",
+			"data": gin.H{
+				"sponsor": toSponsorDetailResponse(sponsors[0]),
+				"stats":   buildSponsorStats(orders),
+				"orders":  list,
+			},
+		})
+	})
+}
+
+// parseSponsorListQuery 解析 /sponsor 的查询参数，参数非法时已写入 400 响应
+func parseSponsorListQuery(c *gin.Context) (sponsorListQuery, bool) {
+	query := sponsorListQuery{
+		sort:  "last_pay_time",
+		order: "desc",
+	}
+
+	var ok bool
+	query.page, query.perPage, ok = parsePagination(c)
+	if !ok {
+		return query, false
+	}
+
+	if raw := c.Query("sort"); raw != "" {
+		if _, exists := sponsorSortColumns[raw]; !exists {
+			respondBadRequest(c, "sort 必须是 last_pay_time、first_pay_time、all_sum_amount 或 name")
+			return query, false
+		}
+		query.sort = raw
+	}
+
+	if raw := c.Query("order"); raw != "" {
+		raw = strings.ToLower(raw)
+		if raw != "asc" && raw != "desc" {
+			respondBadRequest(c, "order 必须是 asc 或 desc")
+			return query, false
+		}
+		query.order = raw
+	}
+
+	query.cursor, query.cursorMode, ok = parseCursor(c)
+	if !ok {
+		return query, false
+	}
+	if query.cursorMode && query.sort != "last_pay_time" {
+		respondBadRequest(c, "游标分页仅支持按 last_pay_time 排序")
+		return query, false
+	}
+
+	if raw := c.Query("min_amount"); raw != "" {
+		amount, err := models.ParseMoney(raw)
+		if err != nil || amount < 0 {
+			respondBadRequest(c, "min_amount 必须是最多两位小数的非负数")
+			return query, false
+		}
+		query.minAmount = &amount
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **int64
+	}{
+		{"since", &query.since},
+		{"until", &query.until},
+	} {
+		raw := c.Query(param.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			respondBadRequest(c, param.name+" 必须是整数")
+			return query, false
+		}
+		*param.target = &value
+	}
+
+	query.name = strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(query.name) > sponsorNameSearchMaxLen {
+		respondBadRequest(c, "q 长度不能超过 "+strconv.Itoa(sponsorNameSearchMaxLen)+" 个字符")
+		return query, false
+	}
+
+	return query, true
+}
+
+// apply 在查询上追加筛选条件，返回的新会话可分别用于 Count 与 Find
+func (q sponsorListQuery) apply(tx *gorm.DB) *gorm.DB {
+	if q.minAmount != nil {
+		tx = tx.Where("all_sum_amount >= ?", *q.minAmount)
+	}
+	if q.since != nil {
+		tx = tx.Where("last_pay_time >= ?", *q.since)
+	}
+	if q.until != nil {
+		tx = tx.Where("last_pay_time <= ?", *q.until)
+	}
+	if q.name != "" {
+		// PostgreSQL 的 LIKE 区分大小写，统一转为小写比较
+		tx = tx.Where("LOWER(name) LIKE ? ESCAPE '!'", "%"+escapeLike(strings.ToLower(q.name))+"%")
+	}
+	return tx.Session(&gorm.Session{})
+}
+
+// orderBy 返回白名单内的排序表达式，不包含用户输入
+func (q sponsorListQuery) orderBy() string {
+	return sponsorSortColumns[q.sort] + " " + q.order
+}
+
+// escapeLike 转义 LIKE 通配符，配合 ESCAPE '!' 使用
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
+
+func toSponsorDetailResponse(sponsor models.Sponsor) sponsorDetailResponse {
+	response := sponsorDetailResponse{
+		UserID:       sponsor.UserID,
+		Name:         sponsor.Name,
+		Avatar:       sponsor.Avatar,
+		AllSumAmount: sponsor.AllSumAmount,
+		CreateTime:   sponsor.CreateTime,
+		FirstPayTime: sponsor.FirstPayTime,
+		LastPayTime:  sponsor.LastPayTime,
+		UpdatedAt:    sponsor.UpdatedAt,
+	}
+	if sponsor.DeletedAt.Valid {
+		deletedAt := sponsor.DeletedAt.Time.Unix()
+		response.DeletedAt = &deletedAt
+	}
+	return response
+}
+
+// buildSponsorStats 汇总赞助者的订单统计，orders 需按创建时间倒序排列；
+// 方案与赞助月数只统计已支付的订单
+func buildSponsorStats(orders []models.Order) sponsorStatsResponse {
+	stats := sponsorStatsResponse{OrderCount: len(orders)}
+	for _, order := range orders {
+		if order.Status != orderStatusPaid {
+			continue
+		}
+		stats.MonthsSponsored += order.Month
+		if order.PlanID == nil || *order.PlanID == "" {
+			continue
+		}
+		if stats.LastPlanID == nil {
+			stats.LastPlanID = order.PlanID
+		}
+		stats.FirstPlanID = order.PlanID
+	}
+	return stats
+}
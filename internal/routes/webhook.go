@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+
+	"afdianapi/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func registerWebhook(router *gin.Engine, db *gorm.DB, client *services.AfdianClient) {
+	router.POST("/webhook/afdian", func(c *gin.Context) {
+		var payload services.WebhookPayload
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"ec": 400,
+				"em": "请求体格式错误",
+			})
+			return
+		}
+
+		if payload.Data.Type != "order" {
+			c.JSON(http.StatusOK, gin.H{
+				"ec": 200,
+				"em": "",
+			})
+			return
+		}
+
+		order, err := client.VerifyWebhookOrder(c.Request.Context(), &payload.Data.Order)
+		if err != nil {
+			log.Printf("[Webhook] 订单 %s 校验失败: %v", payload.Data.Order.OutTradeNo, err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"ec": 400,
+				"em": "订单校验失败",
+			})
+			return
+		}
+
+		record, err := order.ToModel()
+		if err != nil {
+			log.Printf("[Webhook] 订单 %s 数据无效: %v", order.OutTradeNo, err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"ec": 400,
+				"em": "订单数据无效",
+			})
+			return
+		}
+		_, err = services.SaveOrder(c.Request.Context(), db, &record, true)
+		if err != nil {
+			log.Printf("[Webhook] 保存订单 %s 失败: %v", order.OutTradeNo, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"ec": 500,
+				"em": "服务器内部错误",
+			})
+			return
+		}
+
+		log.Printf("[Webhook] 已保存订单 %s", order.OutTradeNo)
+		c.JSON(http.StatusOK, gin.H{
+			"ec": 200,
+			"em": "",
+		})
+	})
+}
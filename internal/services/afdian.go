@@ -1,8 +1,11 @@
 package services
 
 import (
+	"context"
+	"crypto/rsa"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
@@ -10,24 +13,55 @@ import (
 	"afdianapi/internal/utils"
 
 	"github.com/go-resty/resty/v2"
+	"golang.org/x/time/rate"
 )
 
 type AfdianClient struct {
-	client *resty.Client
-	userID string
-	token  string
+	client           *resty.Client
+	userID           string
+	token            string
+	webhookPublicKey *rsa.PublicKey
+	retry            RetryPolicy
+	limiter          *rate.Limiter
 }
 
-func NewAfdianClient(cfg *config.Config) *AfdianClient {
+func NewAfdianClient(cfg *config.Config) (*AfdianClient, error) {
 	client := resty.New().
 		SetBaseURL(cfg.Afdian.BaseURL).
 		SetTimeout(30 * time.Second)
 
-	return &AfdianClient{
-		client: client,
-		userID: cfg.Afdian.UserID,
-		token:  cfg.Afdian.APIToken,
+	var publicKey *rsa.PublicKey
+	if cfg.Afdian.WebhookPublicKey != "" {
+		parsed, err := utils.ParseRSAPublicKey(cfg.Afdian.WebhookPublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("推送签名公钥无效: %w", err)
+		}
+		publicKey = parsed
+	}
+
+	limit := rate.Limit(cfg.Afdian.RateLimit)
+	if cfg.Afdian.RateLimit <= 0 {
+		limit = rate.Inf
+	}
+	burst := cfg.Afdian.RateBurst
+	if burst < 1 {
+		burst = 1
 	}
+
+	return &AfdianClient{
+		client:           client,
+		userID:           cfg.Afdian.UserID,
+		token:            cfg.Afdian.APIToken,
+		webhookPublicKey: publicKey,
+		retry: RetryPolicy{
+			MaxAttempts:         cfg.Afdian.RetryMaxAttempts,
+			BaseDelay:           time.Duration(cfg.Afdian.RetryBaseDelayMs) * time.Millisecond,
+			MaxDelay:            time.Duration(cfg.Afdian.RetryMaxDelayMs) * time.Millisecond,
+			RetryableEc:         cfg.Afdian.RetryableEc,
+			RetryableHTTPStatus: cfg.Afdian.RetryableHTTPStatus,
+		},
+		limiter: rate.NewLimiter(limit, burst),
+	}, nil
 }
 
 type apiResponse struct {
@@ -36,42 +70,76 @@ type apiResponse struct {
 	Data json.RawMessage `json:"data"`
 }
 
-func (c *AfdianClient) request(endpoint string, params interface{}, out interface{}) error {
+// request 发送签名请求，将 data 字段解码到 out 并返回原始 data 以便向前兼容。
+// 可重试的失败按 RetryPolicy 退避重试，非幂等接口默认不重试。
+func (c *AfdianClient) request(ctx context.Context, endpoint string, params interface{}, out interface{}, opts ...CallOption) (json.RawMessage, error) {
+	var options callOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	attempt := 1
+	for {
+		raw, err := c.doRequest(ctx, endpoint, params, out)
+		if err == nil {
+			return raw, nil
+		}
+		if ctx.Err() != nil || attempt >= c.retry.MaxAttempts || !options.retryAllowed() || !c.retry.shouldRetry(err) {
+			return nil, err
+		}
+
+		delay := c.retry.backoff(attempt)
+		log.Printf("[爱发电] %s 第 %d 次请求失败，%s 后重试: %v", endpoint, attempt, delay, err)
+		if err := utils.Sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		attempt++
+	}
+}
+
+// doRequest 执行一次请求，每次调用都会重新生成时间戳与签名。
+// 所有调用（含重试）共享同一个令牌桶，保证整体请求速率不超过配置上限。
+func (c *AfdianClient) doRequest(ctx context.Context, endpoint string, params interface{}, out interface{}) (json.RawMessage, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
 	requestParams, err := utils.BuildRequestParams(params, c.userID, c.token)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	resp, err := c.client.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(requestParams).
 		Post(endpoint)
 	if err != nil {
-		return fmt.Errorf("请求失败: %w", err)
+		return nil, &RequestError{Endpoint: endpoint, Err: err}
 	}
 
 	if resp.StatusCode() != http.StatusOK {
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode(), string(resp.Body()))
+		return nil, &HTTPError{Status: resp.StatusCode(), Body: string(resp.Body()), Endpoint: endpoint}
 	}
 
 	var parsed apiResponse
 	if err := json.Unmarshal(resp.Body(), &parsed); err != nil {
-		return fmt.Errorf("响应解析失败: %w", err)
+		return nil, &DecodeError{Endpoint: endpoint, Err: err}
 	}
 
 	if parsed.Ec != 200 {
-		return fmt.Errorf("API错误: %s", parsed.Em)
+		return nil, &APIError{Ec: parsed.Ec, Em: parsed.Em, Endpoint: endpoint}
 	}
 
-	if out == nil {
-		return nil
+	if out == nil || len(parsed.Data) == 0 {
+		return parsed.Data, nil
 	}
 
 	if err := json.Unmarshal(parsed.Data, out); err != nil {
-		return fmt.Errorf("数据解析失败: %w", err)
+		return nil, &DecodeError{Endpoint: endpoint, Err: err}
 	}
 
-	return nil
+	return parsed.Data, nil
 }
 
 type SponsorUser struct {
@@ -89,78 +157,165 @@ type SponsorItem struct {
 }
 
 type SponsorData struct {
-	TotalCount int           `json:"total_count"`
-	TotalPage  int           `json:"total_page"`
-	List       []SponsorItem `json:"list"`
+	TotalCount int             `json:"total_count"`
+	TotalPage  int             `json:"total_page"`
+	List       []SponsorItem   `json:"list"`
+	Raw        json.RawMessage `json:"-"`
 }
 
-func (c *AfdianClient) QuerySponsor(page int, perPage int) (*SponsorData, error) {
-	params := map[string]interface{}{
-		"page":     page,
-		"per_page": perPage,
-	}
+// QuerySponsorParams /query-sponsor 的查询参数
+type QuerySponsorParams struct {
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+}
 
+func (c *AfdianClient) QuerySponsor(ctx context.Context, params QuerySponsorParams) (*SponsorData, error) {
 	var data SponsorData
-	if err := c.request("/query-sponsor", params, &data); err != nil {
+	raw, err := c.request(ctx, "/query-sponsor", params, &data)
+	if err != nil {
 		return nil, err
 	}
+	data.Raw = raw
 	return &data, nil
 }
 
-func (c *AfdianClient) QueryOrder(params map[string]interface{}) (json.RawMessage, error) {
-	var data json.RawMessage
-	if err := c.request("/query-order", params, &data); err != nil {
+// QueryOrderParams /query-order 的查询参数，按订单号查询时可只填 OutTradeNo
+type QueryOrderParams struct {
+	Page       int    `json:"page,omitempty"`
+	PerPage    int    `json:"per_page,omitempty"`
+	OutTradeNo string `json:"out_trade_no,omitempty"`
+}
+
+func (c *AfdianClient) QueryOrder(ctx context.Context, params QueryOrderParams) (*OrderData, error) {
+	var data OrderData
+	raw, err := c.request(ctx, "/query-order", params, &data)
+	if err != nil {
 		return nil, err
 	}
-	return data, nil
+	data.Raw = raw
+	return &data, nil
 }
 
-func (c *AfdianClient) QueryPlan(planID string) (json.RawMessage, error) {
-	params := map[string]interface{}{
-		"plan_id": planID,
-	}
-	var data json.RawMessage
-	if err := c.request("/query-plan", params, &data); err != nil {
+type PlanInfo struct {
+	PlanID      string          `json:"plan_id"`
+	UserID      string          `json:"user_id"`
+	Rank        int             `json:"rank"`
+	Status      int             `json:"status"`
+	Name        string          `json:"name"`
+	Pic         string          `json:"pic"`
+	Desc        string          `json:"desc"`
+	Price       string          `json:"price"`
+	ShowPrice   string          `json:"show_price"`
+	PayMonth    int             `json:"pay_month"`
+	ProductType int             `json:"product_type"`
+	UpdateTime  int64           `json:"update_time"`
+	Raw         json.RawMessage `json:"-"`
+}
+
+// QueryPlanParams /query-plan 的查询参数
+type QueryPlanParams struct {
+	PlanID string `json:"plan_id"`
+}
+
+func (c *AfdianClient) QueryPlan(ctx context.Context, params QueryPlanParams) (*PlanInfo, error) {
+	var data PlanInfo
+	raw, err := c.request(ctx, "/query-plan", params, &data)
+	if err != nil {
 		return nil, err
 	}
-	return data, nil
+	data.Raw = raw
+	return &data, nil
 }
 
-func (c *AfdianClient) SendMsg(recipient string, content string) (json.RawMessage, error) {
-	params := map[string]interface{}{
-		"recipient": recipient,
-		"content":   content,
-	}
-	var data json.RawMessage
-	if err := c.request("/send-msg", params, &data); err != nil {
+// SendMsgResult /send-msg 的返回结果，接口未约定 data 结构，仅保留原始数据
+type SendMsgResult struct {
+	Raw json.RawMessage `json:"-"`
+}
+
+// SendMsgParams /send-msg 的请求参数，Recipient 为接收者的 user_id
+type SendMsgParams struct {
+	Recipient string `json:"recipient"`
+	Content   string `json:"content"`
+}
+
+// SendMsg 发送私信。该接口非幂等，默认不重试，需要重试时传入 WithRetry()
+func (c *AfdianClient) SendMsg(ctx context.Context, params SendMsgParams, opts ...CallOption) (*SendMsgResult, error) {
+	raw, err := c.request(ctx, "/send-msg", params, nil, append(opts, nonIdempotent())...)
+	if err != nil {
 		return nil, err
 	}
-	return data, nil
+	return &SendMsgResult{Raw: raw}, nil
 }
 
-func (c *AfdianClient) QueryRandomReply(outTradeNo string) (json.RawMessage, error) {
-	params := map[string]interface{}{
-		"out_trade_no": outTradeNo,
-	}
-	var data json.RawMessage
-	if err := c.request("/query-random-reply", params, &data); err != nil {
+type RandomReplyItem struct {
+	OutTradeNo string `json:"out_trade_no"`
+	Content    string `json:"content"`
+}
+
+type RandomReplyData struct {
+	List []RandomReplyItem `json:"list"`
+	Raw  json.RawMessage   `json:"-"`
+}
+
+// QueryRandomReplyParams /query-random-reply 的查询参数
+type QueryRandomReplyParams struct {
+	OutTradeNo string `json:"out_trade_no"`
+}
+
+func (c *AfdianClient) QueryRandomReply(ctx context.Context, params QueryRandomReplyParams) (*RandomReplyData, error) {
+	var data RandomReplyData
+	raw, err := c.request(ctx, "/query-random-reply", params, &data)
+	if err != nil {
 		return nil, err
 	}
-	return data, nil
+	data.Raw = raw
+	return &data, nil
 }
 
-func (c *AfdianClient) UpdatePlanReply(params map[string]interface{}) (json.RawMessage, error) {
-	var data json.RawMessage
-	if err := c.request("/update-plan-reply", params, &data); err != nil {
+// UpdatePlanReplyResult /update-plan-reply 的返回结果，接口未约定 data 结构，仅保留原始数据
+type UpdatePlanReplyResult struct {
+	Raw json.RawMessage `json:"-"`
+}
+
+// UpdatePlanReplyParams /update-plan-reply 的请求参数，修改方案的感谢回复内容
+type UpdatePlanReplyParams struct {
+	PlanID       string `json:"plan_id"`
+	ReplyContent string `json:"reply_content"`
+}
+
+func (c *AfdianClient) UpdatePlanReply(ctx context.Context, params UpdatePlanReplyParams) (*UpdatePlanReplyResult, error) {
+	raw, err := c.request(ctx, "/update-plan-reply", params, nil)
+	if err != nil {
 		return nil, err
 	}
-	return data, nil
+	return &UpdatePlanReplyResult{Raw: raw}, nil
+}
+
+// PingRequest 爱发电回显的签名请求，用于排查签名问题
+type PingRequest struct {
+	UserID string `json:"user_id"`
+	Params string `json:"params"`
+	Ts     int64  `json:"ts"`
+	Sign   string `json:"sign"`
+}
+
+type PingResult struct {
+	UID     string          `json:"uid"`
+	Request PingRequest     `json:"request"`
+	Raw     json.RawMessage `json:"-"`
 }
 
-func (c *AfdianClient) Ping(params map[string]interface{}) (json.RawMessage, error) {
-	var data json.RawMessage
-	if err := c.request("/ping", params, &data); err != nil {
+// PingParams /ping 的请求参数，接口原样回显在 PingResult.Request.Params 中，可用于确认签名的参数内容
+type PingParams struct {
+	Message string `json:"message,omitempty"`
+}
+
+func (c *AfdianClient) Ping(ctx context.Context, params PingParams) (*PingResult, error) {
+	var data PingResult
+	raw, err := c.request(ctx, "/ping", params, &data)
+	if err != nil {
 		return nil, err
 	}
-	return data, nil
+	data.Raw = raw
+	return &data, nil
 }
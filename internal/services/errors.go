@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// 爱发电开放接口的业务错误码
+const (
+	EcParamsIncomplete = 400001
+	EcTimeExpired      = 400002
+	EcParamsNotJSON    = 400003
+	EcTokenInvalid     = 400004
+	EcSignInvalid      = 400005
+)
+
+var (
+	// ErrSignatureInvalid 签名校验失败（ec=400005）
+	ErrSignatureInvalid = errors.New("签名校验失败")
+	// ErrUnauthorized 凭据无效（ec=400004 或 HTTP 401/403）
+	ErrUnauthorized = errors.New("未授权")
+)
+
+// APIError 爱发电接口返回了 ec != 200
+type APIError struct {
+	Ec       int
+	Em       string
+	Endpoint string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API错误 %s (ec=%d): %s", e.Endpoint, e.Ec, e.Em)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrSignatureInvalid:
+		return e.Ec == EcSignInvalid
+	case ErrUnauthorized:
+		return e.Ec == EcTokenInvalid
+	}
+	return false
+}
+
+// HTTPError 爱发电接口返回了非 200 的 HTTP 状态码
+type HTTPError struct {
+	Status   int
+	Body     string
+	Endpoint string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d %s: %s", e.Status, e.Endpoint, e.Body)
+}
+
+func (e *HTTPError) Is(target error) bool {
+	if target == ErrUnauthorized {
+		return e.Status == http.StatusUnauthorized || e.Status == http.StatusForbidden
+	}
+	return false
+}
+
+// RequestError 请求未能得到响应（网络错误、超时等）
+type RequestError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("请求失败 %s: %v", e.Endpoint, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError 响应体无法解析
+type DecodeError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("响应解析失败 %s: %v", e.Endpoint, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
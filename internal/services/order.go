@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"afdianapi/internal/events"
+	"afdianapi/internal/models"
+	"afdianapi/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SkuDetail struct {
+	SkuID   string `json:"sku_id"`
+	Count   int    `json:"count"`
+	Name    string `json:"name"`
+	AlbumID string `json:"album_id"`
+	Pic     string `json:"pic"`
+}
+
+type OrderItem struct {
+	OutTradeNo     string      `json:"out_trade_no"`
+	CustomOrderID  string      `json:"custom_order_id"`
+	UserID         string      `json:"user_id"`
+	UserPrivateID  string      `json:"user_private_id"`
+	PlanID         string      `json:"plan_id"`
+	Month          int         `json:"month"`
+	TotalAmount    string      `json:"total_amount"`
+	ShowAmount     string      `json:"show_amount"`
+	Status         int         `json:"status"`
+	Remark         string      `json:"remark"`
+	RedeemID       string      `json:"redeem_id"`
+	ProductType    int         `json:"product_type"`
+	Discount       string      `json:"discount"`
+	SkuDetail      []SkuDetail `json:"sku_detail"`
+	AddressPerson  string      `json:"address_person"`
+	AddressPhone   string      `json:"address_phone"`
+	AddressAddress string      `json:"address_address"`
+	CreateTime     int64       `json:"create_time"`
+	Sign           string      `json:"sign"`
+}
+
+type OrderData struct {
+	TotalCount int             `json:"total_count"`
+	TotalPage  int             `json:"total_page"`
+	List       []OrderItem     `json:"list"`
+	Raw        json.RawMessage `json:"-"`
+}
+
+// WebhookPayload 爱发电订单推送的请求体
+type WebhookPayload struct {
+	Ec   int    `json:"ec"`
+	Em   string `json:"em"`
+	Data struct {
+		Type  string    `json:"type"`
+		Order OrderItem `json:"order"`
+	} `json:"data"`
+}
+
+// ToModel 将爱发电订单转换为数据库模型，空字符串字段写入 NULL，金额格式无效时返回错误
+func (o OrderItem) ToModel() (models.Order, error) {
+	var amounts [3]models.Money
+	for i, raw := range []string{o.TotalAmount, o.ShowAmount, o.Discount} {
+		amount, err := models.ParseMoney(raw)
+		if err != nil {
+			return models.Order{}, fmt.Errorf("订单 %s: %w", o.OutTradeNo, err)
+		}
+		amounts[i] = amount
+	}
+
+	now := time.Now().Unix()
+	createdAt := o.CreateTime
+	if createdAt == 0 {
+		createdAt = now
+	}
+
+	month := o.Month
+	if month == 0 {
+		month = 1
+	}
+
+	skus := make([]models.OrderSku, 0, len(o.SkuDetail))
+	for _, sku := range o.SkuDetail {
+		count := sku.Count
+		if count == 0 {
+			count = 1
+		}
+		skus = append(skus, models.OrderSku{
+			OutTradeNo: o.OutTradeNo,
+			SkuID:      sku.SkuID,
+			Count:      count,
+			Name:       stringPtrOrNil(sku.Name),
+			AlbumID:    stringPtrOrNil(sku.AlbumID),
+			Pic:        stringPtrOrNil(sku.Pic),
+		})
+	}
+
+	return models.Order{
+		OutTradeNo:     o.OutTradeNo,
+		CustomOrderID:  stringPtrOrNil(o.CustomOrderID),
+		UserID:         o.UserID,
+		UserPrivateID:  stringPtrOrNil(o.UserPrivateID),
+		PlanID:         stringPtrOrNil(o.PlanID),
+		Month:          month,
+		TotalAmount:    amounts[0],
+		ShowAmount:     amounts[1],
+		Status:         o.Status,
+		Remark:         stringPtrOrNil(o.Remark),
+		RedeemID:       stringPtrOrNil(o.RedeemID),
+		ProductType:    o.ProductType,
+		Discount:       amounts[2],
+		AddressPerson:  stringPtrOrNil(o.AddressPerson),
+		AddressPhone:   stringPtrOrNil(o.AddressPhone),
+		AddressAddress: stringPtrOrNil(o.AddressAddress),
+		CreatedAt:      createdAt,
+		UpdatedAt:      now,
+		Skus:           skus,
+	}, nil
+}
+
+// orderUpdateColumns 订单已存在时更新的列
+var orderUpdateColumns = []string{
+	"custom_order_id",
+	"user_id",
+	"user_private_id",
+	"plan_id",
+	"month",
+	"total_amount",
+	"show_amount",
+	"status",
+	"remark",
+	"redeem_id",
+	"product_type",
+	"discount",
+	"address_person",
+	"address_phone",
+	"address_address",
+	"updated_at",
+}
+
+// SaveOrder 在同一事务中写入订单并整体替换其 SKU 记录，返回订单是否为新增。
+// 是否新增以插入语句实际写入的行数为准，Webhook 与定时同步并发处理同一订单时只有一方为新增。
+// publishEvent 为 true 且订单为新增时，order.created 事件也在该事务中写入
+func SaveOrder(ctx context.Context, db *gorm.DB, order *models.Order, publishEvent bool) (bool, error) {
+	skus := order.Skus
+	created := false
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "out_trade_no"}},
+			DoNothing: true,
+		}).Create(order)
+		if result.Error != nil {
+			return result.Error
+		}
+		created = result.RowsAffected > 0
+
+		if !created {
+			if err := tx.Model(order).Select(orderUpdateColumns).Updates(order).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("out_trade_no = ?", order.OutTradeNo).Delete(&models.OrderSku{}).Error; err != nil {
+			return err
+		}
+
+		if len(skus) > 0 {
+			for i := range skus {
+				skus[i].ID = 0
+				skus[i].OutTradeNo = order.OutTradeNo
+			}
+			if err := tx.Create(&skus).Error; err != nil {
+				return err
+			}
+		}
+
+		if created && publishEvent {
+			return events.PublishOrderCreated(ctx, tx, *order)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return created, nil
+}
+
+// VerifyWebhookOrder 校验推送订单的真实性并返回可信的订单数据。
+// 配置了推送公钥时校验签名；否则通过 /query-order 回查订单。
+func (c *AfdianClient) VerifyWebhookOrder(ctx context.Context, order *OrderItem) (*OrderItem, error) {
+	if order.OutTradeNo == "" {
+		return nil, fmt.Errorf("订单号为空")
+	}
+
+	if c.webhookPublicKey != nil {
+		if order.Sign == "" {
+			return nil, fmt.Errorf("缺少签名")
+		}
+		if err := utils.VerifyOrderSign(c.webhookPublicKey, order.OutTradeNo, order.UserID, order.PlanID, order.TotalAmount, order.Sign); err != nil {
+			return nil, err
+		}
+		return order, nil
+	}
+
+	data, err := c.QueryOrder(ctx, QueryOrderParams{OutTradeNo: order.OutTradeNo})
+	if err != nil {
+		return nil, fmt.Errorf("回查订单失败: %w", err)
+	}
+
+	for i := range data.List {
+		if data.List[i].OutTradeNo == order.OutTradeNo {
+			return &data.List[i], nil
+		}
+	}
+	return nil, fmt.Errorf("订单不存在: %s", order.OutTradeNo)
+}
+
+func stringPtrOrNil(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
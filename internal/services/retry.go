@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// RetryPolicy 控制爱发电接口调用失败后的重试行为
+type RetryPolicy struct {
+	// MaxAttempts 最大尝试次数（含首次），小于等于 1 表示不重试
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// RetryableEc 可重试的业务错误码，例如时间戳过期（400002）重新签名即可恢复
+	RetryableEc []int
+	// RetryableHTTPStatus 可重试的 HTTP 状态码
+	RetryableHTTPStatus []int
+}
+
+// CallOption 单次调用的可选参数
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	nonIdempotent bool
+	forceRetry    bool
+}
+
+// WithRetry 允许对非幂等接口（如 SendMsg）进行重试，调用方需自行承担重复执行的风险
+func WithRetry() CallOption {
+	return func(o *callOptions) {
+		o.forceRetry = true
+	}
+}
+
+func nonIdempotent() CallOption {
+	return func(o *callOptions) {
+		o.nonIdempotent = true
+	}
+}
+
+func (o callOptions) retryAllowed() bool {
+	return !o.nonIdempotent || o.forceRetry
+}
+
+func (p RetryPolicy) shouldRetry(err error) bool {
+	var requestErr *RequestError
+	if errors.As(err, &requestErr) {
+		return true
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return slices.Contains(p.RetryableHTTPStatus, httpErr.Status)
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return slices.Contains(p.RetryableEc, apiErr.Ec)
+	}
+
+	return false
+}
+
+// backoff 返回第 attempt 次失败后的等待时间：指数退避并在后半段区间内随机抖动
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
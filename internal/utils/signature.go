@@ -1,10 +1,17 @@
 package utils
 
 import (
+	"crypto"
 	"crypto/md5"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -43,3 +50,38 @@ func BuildRequestParams(params interface{}, userID string, token string) (map[st
 		"sign":    sign,
 	}, nil
 }
+
+// ParseRSAPublicKey 解析 PEM 格式的 RSA 公钥，允许以字面量 \n 代替换行
+func ParseRSAPublicKey(pemString string) (*rsa.PublicKey, error) {
+	pemString = strings.ReplaceAll(pemString, `\n`, "\n")
+	block, _ := pem.Decode([]byte(pemString))
+	if block == nil {
+		return nil, fmt.Errorf("公钥格式无效: 未找到 PEM 数据")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("公钥解析失败: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("公钥类型错误: 需要 RSA 公钥")
+	}
+	return rsaKey, nil
+}
+
+// VerifyOrderSign 校验爱发电订单推送签名
+// 签名规则: base64(rsa_sha256(out_trade_no + user_id + plan_id + total_amount))
+func VerifyOrderSign(publicKey *rsa.PublicKey, outTradeNo string, userID string, planID string, totalAmount string, sign string) error {
+	signature, err := base64.StdEncoding.DecodeString(sign)
+	if err != nil {
+		return fmt.Errorf("签名解码失败: %w", err)
+	}
+
+	hash := sha256.Sum256([]byte(outTradeNo + userID + planID + totalAmount))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signature); err != nil {
+		return fmt.Errorf("签名校验失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// Sleep 等待指定时长，ctx 被取消时提前返回 ctx.Err()
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}